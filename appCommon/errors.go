@@ -4,12 +4,156 @@ import "fmt"
 
 var (
 	KeyDoesNotExist = fmt.Errorf("key does not exist")
+	InvalidTTL      = fmt.Errorf("ttl must be positive")
+	OutOfMemory     = fmt.Errorf("out of memory: write rejected by the memory limit")
+	WatcherClosed   = fmt.Errorf("watcher is closed")
+	Deadlock        = fmt.Errorf("deadlock: the holder of the lock waits for a lock of this transaction")
 )
 
 func NewTxIDDoesNotExistError(txID int) error {
 	return fmt.Errorf("transaction %d does not exist", txID)
 }
 
+// TxConflictError is returned by Commit when another transaction committed a
+// key this transaction depends on after it started. Retrying the whole
+// transaction on a fresh snapshot may succeed.
+type TxConflictError struct {
+	TxID int
+}
+
+func (e *TxConflictError) Error() string {
+	return fmt.Sprintf("transaction %d cannot be committed", e.TxID)
+}
+
 func NewTxIDCanNotBeCommited(txID int) error {
-	return fmt.Errorf("transaction %d cannot be committed", txID)
+	return &TxConflictError{TxID: txID}
+}
+
+// VersionMismatchError is returned by a conditional write whose expected
+// version of Key is not the current one. Current is 0 for a key with no version.
+type VersionMismatchError struct {
+	Key      string
+	Expected int
+	Current  int
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("key %q is at version %d, not %d", e.Key, e.Current, e.Expected)
+}
+
+func NewVersionMismatchError(key string, expected, current int) error {
+	return &VersionMismatchError{Key: key, Expected: expected, Current: current}
+}
+
+// TxExpiredError is returned by the operations of a transaction that ran past
+// its timeout or whose context is done. Such a transaction is aborted.
+type TxExpiredError struct {
+	TxID int
+}
+
+func (e *TxExpiredError) Error() string {
+	return fmt.Sprintf("transaction %d has expired", e.TxID)
+}
+
+func NewTxExpiredError(txID int) error {
+	return &TxExpiredError{TxID: txID}
+}
+
+// KeyLockedError is returned when Key is locked by transaction TxID: right
+// away when failing fast, or with Err set once the context of the wait is done.
+type KeyLockedError struct {
+	Key  string
+	TxID int
+	Err  error
+}
+
+func (e *KeyLockedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("key %q is locked by transaction %d: %v", e.Key, e.TxID, e.Err)
+	}
+	return fmt.Sprintf("key %q is locked by transaction %d", e.Key, e.TxID)
+}
+
+func (e *KeyLockedError) Unwrap() error {
+	return e.Err
+}
+
+func NewKeyLockedError(key string, txID int, err error) error {
+	return &KeyLockedError{Key: key, TxID: txID, Err: err}
+}
+
+// ReadOnlyTxError is returned when a read-only transaction is asked to write.
+type ReadOnlyTxError struct {
+	TxID int
+}
+
+func (e *ReadOnlyTxError) Error() string {
+	return fmt.Sprintf("transaction %d is read-only", e.TxID)
+}
+
+func NewReadOnlyTxError(txID int) error {
+	return &ReadOnlyTxError{TxID: txID}
+}
+
+// DecodeError is returned by the typed API when a stored value cannot be
+// decoded into the requested type, e.g. because it was written by another codec.
+type DecodeError struct {
+	Key string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cannot decode value of key %q: %v", e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func NewDecodeError(key string, err error) error {
+	return &DecodeError{Key: key, Err: err}
+}
+
+func NewIndexAlreadyExistsError(name string) error {
+	return fmt.Errorf("index %q already exists", name)
+}
+
+func NewIndexDoesNotExistError(name string) error {
+	return fmt.Errorf("index %q does not exist", name)
+}
+
+func NewSavepointDoesNotExistError(name string) error {
+	return fmt.Errorf("savepoint %q does not exist", name)
+}
+
+// UniqueViolationError is returned when a write would give Key the same value
+// as ConflictingKey in the unique index Index.
+type UniqueViolationError struct {
+	Index          string
+	Key            string
+	ConflictingKey string
+}
+
+func (e *UniqueViolationError) Error() string {
+	return fmt.Sprintf("unique index %q violated: key %q has the same value as key %q", e.Index, e.Key, e.ConflictingKey)
+}
+
+func NewUniqueViolationError(index, key, conflictingKey string) error {
+	return &UniqueViolationError{Index: index, Key: key, ConflictingKey: conflictingKey}
+}
+
+// ChangelogTruncatedError is returned by a subscription whose next commit is
+// no longer kept in the changelog. The subscriber has to reload the state and
+// subscribe again from OldestTxID.
+type ChangelogTruncatedError struct {
+	AfterTxID  int
+	OldestTxID int
+}
+
+func (e *ChangelogTruncatedError) Error() string {
+	return fmt.Sprintf("commits after transaction %d are no longer kept, the oldest one is %d", e.AfterTxID, e.OldestTxID)
+}
+
+func NewChangelogTruncatedError(afterTxID, oldestTxID int) error {
+	return &ChangelogTruncatedError{AfterTxID: afterTxID, OldestTxID: oldestTxID}
 }
@@ -0,0 +1,311 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+const defaultMaxHistory = 1000
+
+// Editor reads lines with emacs style editing keys and a history navigable
+// with the arrow keys. When the input is not a terminal it falls back to plain
+// line reading so scripts can be piped in.
+type Editor struct {
+	reader      *bufio.Reader
+	out         io.Writer
+	interactive bool
+	raw         func() (func(), error)
+	history     []string
+	maxHistory  int
+}
+
+// New creates an editor on a terminal, or a plain line reader if in is not one.
+func New(in *os.File, out io.Writer) *Editor {
+	fd := int(in.Fd())
+	interactive := isTerminal(fd)
+	editor := NewWithIO(in, out, interactive)
+	if interactive {
+		editor.raw = func() (func(), error) { return makeRaw(fd) }
+	}
+	return editor
+}
+
+// NewWithIO creates an editor on arbitrary streams. With interactive set the
+// input is interpreted as key presses (useful in tests), the caller is in
+// charge of the terminal mode.
+func NewWithIO(in io.Reader, out io.Writer, interactive bool) *Editor {
+	return &Editor{
+		reader:      bufio.NewReader(in),
+		out:         out,
+		interactive: interactive,
+		raw:         func() (func(), error) { return func() {}, nil },
+		maxHistory:  defaultMaxHistory,
+	}
+}
+
+func (e *Editor) Interactive() bool {
+	return e.interactive
+}
+
+// AddHistory appends a line to the history, skipping blanks and repeats.
+func (e *Editor) AddHistory(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > e.maxHistory {
+		e.history = e.history[len(e.history)-e.maxHistory:]
+	}
+}
+
+func (e *Editor) History() []string {
+	return append([]string{}, e.history...)
+}
+
+// LoadHistory reads a history file written by SaveHistory, a missing file is
+// not an error.
+func (e *Editor) LoadHistory(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		e.AddHistory(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func (e *Editor) SaveHistory(path string) error {
+	return os.WriteFile(path, []byte(strings.Join(e.history, "\n")+"\n"), 0o600)
+}
+
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.interactive {
+		return e.readPlainLine()
+	}
+
+	restore, err := e.raw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	_, _ = fmt.Fprint(e.out, prompt)
+	return e.edit(prompt)
+}
+
+func (e *Editor) readPlainLine() (string, error) {
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineState is the line being edited. historyIndex == len(history) means the
+// user is on the fresh line, whose content is kept in draft while browsing.
+type lineState struct {
+	prompt       string
+	buffer       []rune
+	cursor       int
+	historyIndex int
+	draft        []rune
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyCtrlK     = 11
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+func (e *Editor) edit(prompt string) (string, error) {
+	state := &lineState{prompt: prompt, historyIndex: len(e.history)}
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, '\n':
+			_, _ = fmt.Fprint(e.out, "\r\n")
+			return string(state.buffer), nil
+		case keyCtrlC:
+			_, _ = fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(state.buffer) == 0 {
+				_, _ = fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			state.deleteAt(state.cursor)
+		case keyDelete, keyBackspace:
+			if state.cursor > 0 {
+				state.cursor--
+				state.deleteAt(state.cursor)
+			}
+		case keyCtrlA:
+			state.cursor = 0
+		case keyCtrlE:
+			state.cursor = len(state.buffer)
+		case keyCtrlB:
+			state.moveCursor(-1)
+		case keyCtrlF:
+			state.moveCursor(1)
+		case keyCtrlK:
+			state.buffer = state.buffer[:state.cursor]
+		case keyCtrlU:
+			state.buffer = state.buffer[state.cursor:]
+			state.cursor = 0
+		case keyCtrlW:
+			state.deleteWordBackward()
+		case keyCtrlP:
+			e.browseHistory(state, -1)
+		case keyCtrlN:
+			e.browseHistory(state, 1)
+		case keyEscape:
+			if err := e.handleEscape(state); err != nil {
+				return "", err
+			}
+		default:
+			if r >= 32 {
+				state.insert(r)
+			}
+		}
+		e.refresh(state)
+	}
+}
+
+// handleEscape interprets the ANSI sequences sent by arrow, home, end and
+// delete keys.
+func (e *Editor) handleEscape(state *lineState) error {
+	next, _, err := e.reader.ReadRune()
+	if err != nil {
+		return err
+	}
+	if next != '[' && next != 'O' {
+		return nil
+	}
+
+	code, _, err := e.reader.ReadRune()
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 'A':
+		e.browseHistory(state, -1)
+	case 'B':
+		e.browseHistory(state, 1)
+	case 'C':
+		state.moveCursor(1)
+	case 'D':
+		state.moveCursor(-1)
+	case 'H':
+		state.cursor = 0
+	case 'F':
+		state.cursor = len(state.buffer)
+	case '1', '3', '4', '7', '8':
+		if tilde, _, err := e.reader.ReadRune(); err != nil || tilde != '~' {
+			return err
+		}
+		switch code {
+		case '1', '7':
+			state.cursor = 0
+		case '4', '8':
+			state.cursor = len(state.buffer)
+		case '3':
+			state.deleteAt(state.cursor)
+		}
+	}
+	return nil
+}
+
+func (e *Editor) browseHistory(state *lineState, direction int) {
+	index := state.historyIndex + direction
+	if index < 0 || index > len(e.history) {
+		return
+	}
+	if state.historyIndex == len(e.history) {
+		state.draft = append([]rune{}, state.buffer...)
+	}
+
+	state.historyIndex = index
+	if index == len(e.history) {
+		state.buffer = append([]rune{}, state.draft...)
+	} else {
+		state.buffer = []rune(e.history[index])
+	}
+	state.cursor = len(state.buffer)
+}
+
+// refresh redraws the whole line and puts the cursor back in place.
+func (e *Editor) refresh(state *lineState) {
+	var builder strings.Builder
+	builder.WriteString("\r")
+	builder.WriteString(state.prompt)
+	builder.WriteString(string(state.buffer))
+	builder.WriteString("\x1b[K\r")
+	if column := len([]rune(state.prompt)) + state.cursor; column > 0 {
+		builder.WriteString(fmt.Sprintf("\x1b[%dC", column))
+	}
+	_, _ = fmt.Fprint(e.out, builder.String())
+}
+
+func (state *lineState) insert(r rune) {
+	state.buffer = append(state.buffer, 0)
+	copy(state.buffer[state.cursor+1:], state.buffer[state.cursor:])
+	state.buffer[state.cursor] = r
+	state.cursor++
+}
+
+func (state *lineState) deleteAt(position int) {
+	if position < 0 || position >= len(state.buffer) {
+		return
+	}
+	state.buffer = append(state.buffer[:position], state.buffer[position+1:]...)
+}
+
+func (state *lineState) moveCursor(delta int) {
+	cursor := state.cursor + delta
+	if cursor >= 0 && cursor <= len(state.buffer) {
+		state.cursor = cursor
+	}
+}
+
+func (state *lineState) deleteWordBackward() {
+	end := state.cursor
+	for state.cursor > 0 && state.buffer[state.cursor-1] == ' ' {
+		state.cursor--
+	}
+	for state.cursor > 0 && state.buffer[state.cursor-1] != ' ' {
+		state.cursor--
+	}
+	state.buffer = append(state.buffer[:state.cursor], state.buffer[end:]...)
+}
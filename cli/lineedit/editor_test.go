@@ -0,0 +1,93 @@
+package lineedit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditor_KeyHandling(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"plain line", "GET key\r", "GET key"},
+		{"backspace", "GET keyy\x7f\r", "GET key"},
+		{"left arrow and insert", "GT key\x1b[D\x1b[D\x1b[D\x1b[D\x1b[DE\r", "GET key"},
+		{"home and end", "ET key\x01G\x05s\r", "GET keys"},
+		{"kill to end", "GET key value\x01\x1b[C\x1b[C\x1b[C\x0b\r", "GET"},
+		{"kill to start", "junk GET key\x01\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\x15\r", "GET key"},
+		{"delete word", "GET key junk\x17\x7f\r", "GET key"},
+		{"delete key", "GETT key\x01\x1b[C\x1b[C\x1b[C\x1b[3~\r", "GET key"},
+		{"utf8", "SET k héllo\x7f\x7fo\r", "SET k hélo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor := NewWithIO(strings.NewReader(tt.input), io.Discard, true)
+			line, err := editor.ReadLine("> ")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, line)
+		})
+	}
+}
+
+func TestEditor_History(t *testing.T) {
+	input := "first\rsecond\r\x1b[A\x1b[A\r\x1b[A\x1b[A\x1b[B\x1b[Bdraft\x1b[A\x1b[B\r"
+	editor := NewWithIO(strings.NewReader(input), io.Discard, true)
+
+	var lines []string
+	for i := 0; i < 4; i++ {
+		line, err := editor.ReadLine("> ")
+		assert.NoError(t, err)
+		editor.AddHistory(line)
+		lines = append(lines, line)
+	}
+
+	assert.Equal(t, []string{"first", "second", "first", "draft"}, lines)
+	assert.Equal(t, []string{"first", "second", "first", "draft"}, editor.History())
+}
+
+func TestEditor_ControlKeys(t *testing.T) {
+	editor := NewWithIO(strings.NewReader("abc\x03\x04"), io.Discard, true)
+
+	_, err := editor.ReadLine("> ")
+	assert.ErrorIs(t, err, ErrInterrupted)
+
+	_, err = editor.ReadLine("> ")
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestEditor_PlainInput(t *testing.T) {
+	out := new(bytes.Buffer)
+	editor := NewWithIO(strings.NewReader("GET key\nSET key value"), out, false)
+
+	line, err := editor.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "GET key", line)
+
+	line, err = editor.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "SET key value", line)
+
+	_, err = editor.ReadLine("> ")
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Empty(t, out.String(), "no prompt is echoed for piped input")
+}
+
+func TestEditor_SaveLoadHistory(t *testing.T) {
+	path := t.TempDir() + "/history"
+	editor := NewWithIO(strings.NewReader(""), io.Discard, false)
+	editor.AddHistory("SET a 1")
+	editor.AddHistory("GET a")
+	assert.NoError(t, editor.SaveHistory(path))
+
+	loaded := NewWithIO(strings.NewReader(""), io.Discard, false)
+	assert.NoError(t, loaded.LoadHistory(path))
+	assert.Equal(t, editor.History(), loaded.History())
+	assert.NoError(t, loaded.LoadHistory(path+".missing"))
+}
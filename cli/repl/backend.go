@@ -0,0 +1,31 @@
+package repl
+
+import (
+	"context"
+
+	"in-memory-storage-engine/storage_engine/version"
+)
+
+// queued is returned by backends that buffer commands issued inside a
+// transaction instead of running them right away (the RESP server does so
+// between MULTI and EXEC).
+type queued struct{}
+
+// status is a simple status reply of a remote server, such as OK.
+type status string
+
+// Backend is what the REPL drives, either an embedded store or a remote server.
+type Backend interface {
+	Set(ctx context.Context, key string, value string) (interface{}, error)
+	Get(ctx context.Context, key string) (interface{}, error)
+	Delete(ctx context.Context, key string) (interface{}, error)
+	Begin(ctx context.Context) error
+	// Commit returns the results of queued commands, if the backend queues.
+	Commit(ctx context.Context) ([]interface{}, error)
+	Abort(ctx context.Context) error
+	History(ctx context.Context, key string) ([]version.VersionInfo, error)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	// TxLabel describes the open transaction for the prompt, "" if there is none.
+	TxLabel() string
+	Close() error
+}
@@ -0,0 +1,102 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"in-memory-storage-engine/storage_engine/storage"
+	"in-memory-storage-engine/storage_engine/version"
+)
+
+var errNoTransaction = errors.New("no transaction in progress, use BEGIN first")
+var errNestedTransaction = errors.New("a transaction is already in progress")
+
+type embeddedBackend struct {
+	store storage.MemStorage
+	tx    storage.MemTx
+}
+
+func NewEmbeddedBackend(store storage.MemStorage) Backend {
+	return &embeddedBackend{store: store}
+}
+
+// target is the open transaction if any, the store otherwise. Both share the
+// same Get/Set/Delete signatures.
+func (b *embeddedBackend) target() interface {
+	Set(ctx context.Context, key string, value interface{}) error
+	Get(ctx context.Context, key string) (interface{}, error)
+	Delete(ctx context.Context, key string) error
+} {
+	if b.tx != nil {
+		return b.tx
+	}
+	return b.store
+}
+
+func (b *embeddedBackend) Set(ctx context.Context, key string, value string) (interface{}, error) {
+	return nil, b.target().Set(ctx, key, value)
+}
+
+func (b *embeddedBackend) Get(ctx context.Context, key string) (interface{}, error) {
+	return b.target().Get(ctx, key)
+}
+
+func (b *embeddedBackend) Delete(ctx context.Context, key string) (interface{}, error) {
+	return nil, b.target().Delete(ctx, key)
+}
+
+func (b *embeddedBackend) Begin(ctx context.Context) error {
+	if b.tx != nil {
+		return errNestedTransaction
+	}
+	// an interactive transaction lasts until the user ends it
+	b.tx = b.store.Tx(storage.WithTimeout(0))
+	return nil
+}
+
+func (b *embeddedBackend) Commit(ctx context.Context) ([]interface{}, error) {
+	if b.tx == nil {
+		return nil, errNoTransaction
+	}
+	tx := b.tx
+	b.tx = nil
+
+	if err := tx.Commit(ctx); err != nil {
+		_ = tx.Abort(ctx)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *embeddedBackend) Abort(ctx context.Context) error {
+	if b.tx == nil {
+		return errNoTransaction
+	}
+	tx := b.tx
+	b.tx = nil
+	return tx.Abort(ctx)
+}
+
+func (b *embeddedBackend) History(ctx context.Context, key string) ([]version.VersionInfo, error) {
+	return b.store.History(ctx, key)
+}
+
+func (b *embeddedBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return b.store.Keys(ctx, pattern)
+}
+
+func (b *embeddedBackend) TxLabel() string {
+	if b.tx == nil {
+		return ""
+	}
+	return "tx " + strconv.Itoa(b.tx.ID())
+}
+
+func (b *embeddedBackend) Close() error {
+	if b.tx != nil {
+		_ = b.tx.Abort(context.Background())
+		b.tx = nil
+	}
+	return b.store.Close()
+}
@@ -0,0 +1,195 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/server/resp"
+	"in-memory-storage-engine/storage_engine/version"
+)
+
+var conflictTxID = regexp.MustCompile(`transaction (\d+)`)
+
+// remoteBackend talks to cmd/server over RESP. Transactions map to
+// MULTI/EXEC, so commands inside them are queued and answered on COMMIT.
+type remoteBackend struct {
+	client  *resp.Client
+	inMulti bool
+}
+
+func NewRemoteBackend(addr string) (Backend, error) {
+	client, err := resp.Dial(addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", addr, err)
+	}
+	return &remoteBackend{client: client}, nil
+}
+
+// do runs a command and turns error replies into Go errors, mapping the
+// engine's well known errors back to their types.
+func (b *remoteBackend) do(args ...string) (resp.Value, error) {
+	reply, err := b.client.Do(args...)
+	if err != nil {
+		return resp.Value{}, err
+	}
+	if reply.Type == resp.Error {
+		return reply, remoteError(reply.Str)
+	}
+	return reply, nil
+}
+
+func remoteError(message string) error {
+	if strings.HasPrefix(message, "CONFLICT ") {
+		if match := conflictTxID.FindStringSubmatch(message); match != nil {
+			txID, _ := strconv.Atoi(match[1])
+			return appCommon.NewTxIDCanNotBeCommited(txID)
+		}
+	}
+	if strings.HasSuffix(message, appCommon.KeyDoesNotExist.Error()) {
+		return appCommon.KeyDoesNotExist
+	}
+	return errors.New(strings.TrimPrefix(message, "ERR "))
+}
+
+func (b *remoteBackend) result(reply resp.Value) interface{} {
+	if reply.Type == resp.SimpleString && reply.Str == "QUEUED" {
+		return queued{}
+	}
+	return valueOf(reply)
+}
+
+func valueOf(reply resp.Value) interface{} {
+	switch {
+	case reply.IsNull || reply.Type == resp.Null:
+		return nil
+	case reply.Type == resp.Integer:
+		return reply.Int
+	case reply.Type == resp.Error:
+		return remoteError(reply.Str)
+	case reply.Type == resp.SimpleString:
+		return status(reply.Str)
+	}
+	return reply.Str
+}
+
+func (b *remoteBackend) Set(ctx context.Context, key string, value string) (interface{}, error) {
+	reply, err := b.do("SET", key, value)
+	if err != nil {
+		return nil, err
+	}
+	return b.result(reply), nil
+}
+
+func (b *remoteBackend) Get(ctx context.Context, key string) (interface{}, error) {
+	reply, err := b.do("GET", key)
+	if err != nil {
+		return nil, err
+	}
+	return b.result(reply), nil
+}
+
+func (b *remoteBackend) Delete(ctx context.Context, key string) (interface{}, error) {
+	reply, err := b.do("DEL", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Type == resp.Integer && reply.Int == 0 {
+		return nil, appCommon.KeyDoesNotExist
+	}
+	return b.result(reply), nil
+}
+
+func (b *remoteBackend) Begin(ctx context.Context) error {
+	if b.inMulti {
+		return errNestedTransaction
+	}
+	if _, err := b.do("MULTI"); err != nil {
+		return err
+	}
+	b.inMulti = true
+	return nil
+}
+
+func (b *remoteBackend) Commit(ctx context.Context) ([]interface{}, error) {
+	if !b.inMulti {
+		return nil, errNoTransaction
+	}
+	b.inMulti = false
+
+	reply, err := b.do("EXEC")
+	if err != nil {
+		return nil, err
+	}
+	if reply.IsNull || reply.Type == resp.Null {
+		return nil, appCommon.NewTxIDCanNotBeCommited(0)
+	}
+
+	results := make([]interface{}, len(reply.Elems))
+	for i, elem := range reply.Elems {
+		results[i] = valueOf(elem)
+	}
+	return results, nil
+}
+
+func (b *remoteBackend) Abort(ctx context.Context) error {
+	if !b.inMulti {
+		return errNoTransaction
+	}
+	b.inMulti = false
+	_, err := b.do("DISCARD")
+	return err
+}
+
+func (b *remoteBackend) History(ctx context.Context, key string) ([]version.VersionInfo, error) {
+	reply, err := b.do("HISTORY", key)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]version.VersionInfo, 0, len(reply.Elems))
+	for _, elem := range reply.Elems {
+		if len(elem.Elems) != 4 {
+			return nil, fmt.Errorf("unexpected HISTORY reply")
+		}
+		createdAt, _ := time.Parse(time.RFC3339Nano, elem.Elems[2].Str)
+		history = append(history, version.VersionInfo{
+			TxID:      int(elem.Elems[0].Int),
+			Visible:   elem.Elems[1].Int == 1,
+			CreatedAt: createdAt,
+			Value:     valueOf(elem.Elems[3]),
+		})
+	}
+	return history, nil
+}
+
+func (b *remoteBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	reply, err := b.do("KEYS", pattern)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(reply.Elems))
+	for i, elem := range reply.Elems {
+		keys[i] = elem.Str
+	}
+	return keys, nil
+}
+
+func (b *remoteBackend) TxLabel() string {
+	if b.inMulti {
+		return "multi"
+	}
+	return ""
+}
+
+func (b *remoteBackend) Close() error {
+	if b.inMulti {
+		_, _ = b.do("DISCARD")
+	}
+	return b.client.Close()
+}
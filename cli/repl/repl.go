@@ -0,0 +1,245 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/cli/lineedit"
+)
+
+const helpText = `Commands:
+  SET key value       set key (quote values containing spaces: SET k "a b")
+  GET key             read key
+  DEL key             delete key
+  BEGIN               start a transaction
+  COMMIT              commit the current transaction
+  ABORT               abort the current transaction (alias ROLLBACK)
+  HISTORY key         show the committed versions of key
+  KEYS pattern        list keys matching a glob pattern (*, ?, [abc])
+  HELP                show this help
+  EXIT                leave (alias QUIT)`
+
+type REPL struct {
+	backend Backend
+	editor  *lineedit.Editor
+	out     io.Writer
+}
+
+func New(backend Backend, editor *lineedit.Editor, out io.Writer) *REPL {
+	return &REPL{backend: backend, editor: editor, out: out}
+}
+
+func (r *REPL) prompt() string {
+	if label := r.backend.TxLabel(); label != "" {
+		return fmt.Sprintf("memstore(%s)> ", label)
+	}
+	return "memstore> "
+}
+
+// Run reads and executes commands until EXIT or end of input.
+func (r *REPL) Run(ctx context.Context) error {
+	for {
+		line, err := r.editor.ReadLine(r.prompt())
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		r.editor.AddHistory(line)
+		if quit := r.Execute(ctx, line); quit {
+			return nil
+		}
+	}
+}
+
+// Execute runs one command line and prints its result. It returns true when
+// the user asked to leave.
+func (r *REPL) Execute(ctx context.Context, line string) bool {
+	args, err := tokenize(line)
+	if err != nil {
+		r.printError(err)
+		return false
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	name := strings.ToUpper(args[0])
+	arity := map[string]int{
+		"SET": 3, "GET": 2, "DEL": 2, "BEGIN": 1, "COMMIT": 1, "ABORT": 1, "ROLLBACK": 1,
+		"HISTORY": 2, "KEYS": 2, "HELP": 1, "EXIT": 1, "QUIT": 1,
+	}
+	expected, known := arity[name]
+	if !known {
+		r.printError(fmt.Errorf("unknown command '%s', type HELP for the list of commands", args[0]))
+		return false
+	}
+	if name == "KEYS" && len(args) == 1 {
+		args = append(args, "*")
+	}
+	if len(args) != expected {
+		r.printError(fmt.Errorf("wrong number of arguments for '%s'", strings.ToLower(name)))
+		return false
+	}
+
+	switch name {
+	case "EXIT", "QUIT":
+		return true
+	case "HELP":
+		r.println(helpText)
+	case "SET":
+		r.printResult(r.backend.Set(ctx, args[1], args[2]))
+	case "GET":
+		r.printResult(r.backend.Get(ctx, args[1]))
+	case "DEL":
+		r.printResult(r.backend.Delete(ctx, args[1]))
+	case "BEGIN":
+		r.printResult(nil, r.backend.Begin(ctx))
+	case "COMMIT":
+		results, err := r.backend.Commit(ctx)
+		if err != nil {
+			r.printError(err)
+			return false
+		}
+		r.println("OK")
+		for i, result := range results {
+			r.println(fmt.Sprintf("%d) %s", i+1, render(result)))
+		}
+	case "ABORT", "ROLLBACK":
+		r.printResult(nil, r.backend.Abort(ctx))
+	case "HISTORY":
+		r.history(ctx, args[1])
+	case "KEYS":
+		keys, err := r.backend.Keys(ctx, args[1])
+		if err != nil {
+			r.printError(err)
+			return false
+		}
+		if len(keys) == 0 {
+			r.println("(empty list)")
+		}
+		for i, key := range keys {
+			r.println(fmt.Sprintf("%d) %q", i+1, key))
+		}
+	}
+	return false
+}
+
+func (r *REPL) history(ctx context.Context, key string) {
+	versions, err := r.backend.History(ctx, key)
+	if err != nil {
+		r.printError(err)
+		return
+	}
+	if len(versions) == 0 {
+		r.println("(no versions)")
+		return
+	}
+
+	writer := tabwriter.NewWriter(r.out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "TXID\tVISIBLE\tCREATED AT\tVALUE")
+	for _, version := range versions {
+		value := render(version.Value)
+		if !version.Visible {
+			value = "(deleted)"
+		}
+		_, _ = fmt.Fprintf(writer, "%d\t%t\t%s\t%s\n", version.TxID, version.Visible, version.CreatedAt.Format(time.RFC3339), value)
+	}
+	_ = writer.Flush()
+}
+
+func (r *REPL) printResult(result interface{}, err error) {
+	if err != nil {
+		r.printError(err)
+		return
+	}
+	if result == nil {
+		r.println("OK")
+		return
+	}
+	r.println(render(result))
+}
+
+// printError renders engine errors in a way that tells the user what to do.
+func (r *REPL) printError(err error) {
+	var conflict *appCommon.TxConflictError
+	switch {
+	case errors.Is(err, appCommon.KeyDoesNotExist):
+		r.println("(error) key does not exist")
+	case errors.As(err, &conflict):
+		r.println("(conflict) the transaction was not committed because another one changed the same keys first, run it again")
+	default:
+		r.println("(error) " + err.Error())
+	}
+}
+
+func (r *REPL) println(line string) {
+	_, _ = fmt.Fprintln(r.out, line)
+}
+
+func render(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "(nil)"
+	case queued:
+		return "QUEUED"
+	case status:
+		return string(v)
+	case error:
+		return "(error) " + v.Error()
+	case int64:
+		return fmt.Sprintf("(integer) %d", v)
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// tokenize splits a command line on spaces, honoring double quoted arguments
+// with backslash escapes.
+func tokenize(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuotes, escaped, hasToken := false, false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			hasToken = true
+		case r == ' ' && !inQuotes:
+			if hasToken {
+				args = append(args, current.String())
+				current.Reset()
+				hasToken = false
+			}
+		default:
+			current.WriteRune(r)
+			hasToken = true
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unbalanced quotes")
+	}
+	if hasToken {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
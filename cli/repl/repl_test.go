@@ -0,0 +1,126 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/cli/lineedit"
+	"in-memory-storage-engine/server/respserver"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+func run(t *testing.T, backend Backend, script string) string {
+	out := new(bytes.Buffer)
+	editor := lineedit.NewWithIO(strings.NewReader(script), io.Discard, false)
+	assert.NoError(t, New(backend, editor, out).Run(context.Background()))
+	return out.String()
+}
+
+func TestREPL_Embedded(t *testing.T) {
+	backend := NewEmbeddedBackend(storage.NewMemStore())
+
+	out := run(t, backend, strings.Join([]string{
+		`SET greeting "hello world"`,
+		`GET greeting`,
+		`GET missing`,
+		`DEL missing`,
+		`BEGIN`,
+		`SET greeting bye`,
+		`GET greeting`,
+		`ABORT`,
+		`GET greeting`,
+		`BEGIN`,
+		`DEL greeting`,
+		`COMMIT`,
+		`HISTORY greeting`,
+		`KEYS *`,
+		`COMMIT`,
+		`NOPE`,
+		`EXIT`,
+		`GET never-reached`,
+	}, "\n"))
+
+	assert.Equal(t, strings.Join([]string{
+		`OK`,
+		`"hello world"`,
+		`(error) key does not exist`,
+		`(error) key does not exist`,
+		`OK`,
+		`OK`,
+		`"bye"`,
+		`OK`,
+		`"hello world"`,
+		`OK`,
+		`OK`,
+		`OK`,
+	}, "\n"), strings.Join(strings.Split(out, "\n")[:12], "\n"))
+
+	assert.Contains(t, out, "TXID")
+	assert.Contains(t, out, "(deleted)")
+	assert.Contains(t, out, "(empty list)")
+	assert.Contains(t, out, "(error) no transaction in progress, use BEGIN first")
+	assert.Contains(t, out, "(error) unknown command 'NOPE'")
+	assert.NotContains(t, out, "never-reached")
+}
+
+func TestREPL_Conflict(t *testing.T) {
+	store := storage.NewMemStore()
+	backend := NewEmbeddedBackend(store)
+	repl := New(backend, lineedit.NewWithIO(strings.NewReader(""), io.Discard, false), new(bytes.Buffer))
+	ctx := context.Background()
+
+	out := new(bytes.Buffer)
+	repl.out = out
+	repl.Execute(ctx, "BEGIN")
+	repl.Execute(ctx, "SET key value")
+	assert.NoError(t, store.Set(ctx, "key", "other"))
+	repl.Execute(ctx, "COMMIT")
+
+	assert.Contains(t, out.String(), "(conflict)")
+	assert.Equal(t, "memstore> ", repl.prompt())
+}
+
+func TestREPL_Remote(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := respserver.NewServer(storage.NewMemStore(), logger)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	backend, err := NewRemoteBackend(listener.Addr().String())
+	assert.NoError(t, err)
+	defer backend.Close()
+
+	out := run(t, backend, strings.Join([]string{
+		`SET key1 value1`,
+		`BEGIN`,
+		`SET key2 value2`,
+		`GET key1`,
+		`COMMIT`,
+		`KEYS key*`,
+		`DEL missing`,
+		`HISTORY key1`,
+	}, "\n"))
+
+	assert.Equal(t, strings.Join([]string{
+		`OK`,
+		`OK`,
+		`QUEUED`,
+		`QUEUED`,
+		`OK`,
+		`1) OK`,
+		`2) "value1"`,
+		`1) "key1"`,
+		`2) "key2"`,
+		`(error) key does not exist`,
+	}, "\n"), strings.Join(strings.Split(out, "\n")[:10], "\n"))
+	assert.Contains(t, out, "TXID")
+}
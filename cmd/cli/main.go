@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"in-memory-storage-engine/cli/lineedit"
+	"in-memory-storage-engine/cli/repl"
+	"in-memory-storage-engine/storage_engine/storage"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func main() {
+	connect := flag.String("connect", "", "address of a running cmd/server, empty runs an embedded store")
+	walDir := flag.String("wal-dir", "", "write-ahead log directory of the embedded store")
+	historyFile := flag.String("history", defaultHistoryFile(), "file keeping the command history, empty disables it")
+	flag.Parse()
+
+	var backend repl.Backend
+	if *connect != "" {
+		remote, err := repl.NewRemoteBackend(*connect)
+		if err != nil {
+			log.Fatal(err)
+		}
+		backend = remote
+	} else {
+		var opts []storage.Option
+		if *walDir != "" {
+			opts = append(opts, storage.WithWAL(*walDir, wal.Options{SyncPolicy: wal.SyncEveryCommit}))
+		}
+		store, err := storage.OpenMemStore(opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		backend = repl.NewEmbeddedBackend(store)
+	}
+	defer backend.Close()
+
+	editor := lineedit.New(os.Stdin, os.Stdout)
+	if *historyFile != "" {
+		if err := editor.LoadHistory(*historyFile); err != nil {
+			log.Println("cannot load history:", err)
+		}
+	}
+
+	if err := repl.New(backend, editor, os.Stdout).Run(context.Background()); err != nil {
+		log.Println(err)
+	}
+
+	if *historyFile != "" {
+		if err := editor.SaveHistory(*historyFile); err != nil {
+			log.Println("cannot save history:", err)
+		}
+	}
+}
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".memstore_history")
+}
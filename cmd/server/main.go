@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"in-memory-storage-engine/cronjob"
+	"in-memory-storage-engine/server/admin"
+	"in-memory-storage-engine/server/httpapi"
+	"in-memory-storage-engine/server/respserver"
+	"in-memory-storage-engine/storage_engine/storage"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func main() {
+	addr := flag.String("addr", ":6379", "address of the RESP (Redis protocol) listener")
+	httpAddr := flag.String("http", "", "address of the HTTP/JSON API listener, empty disables it")
+	adminAddr := flag.String("admin", "", "address of the web admin UI, empty disables it")
+	walDir := flag.String("wal-dir", "", "directory of the write-ahead log, empty keeps data in memory only")
+	walSync := flag.String("wal-sync", "always", "wal fsync policy: always, batch or none")
+	maxMemory := flag.Int64("max-memory", 0, "estimated memory limit in bytes, 0 means no limit")
+	eviction := flag.String("eviction", "noeviction", "eviction policy over the memory limit: noeviction, lru, lfu, random or ttl")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{ForceColors: true})
+
+	var opts []storage.Option
+	if *walDir != "" {
+		policy, err := parseSyncPolicy(*walSync)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, storage.WithWAL(*walDir, wal.Options{SyncPolicy: policy}))
+	}
+	if *maxMemory > 0 {
+		policy, err := parseEvictionPolicy(*eviction)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, storage.WithMaxMemory(*maxMemory, policy))
+	}
+
+	store, err := storage.OpenMemStore(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cronjob.RemoveOldVersionKey(store); err != nil {
+		log.Fatal(err)
+	}
+	if err := cronjob.RemoveExpiredKey(store); err != nil {
+		log.Fatal(err)
+	}
+	if err := cronjob.RemoveExpiredTransaction(store); err != nil {
+		log.Fatal(err)
+	}
+
+	var api httpapi.Server
+	if *httpAddr != "" {
+		api = httpapi.NewServer(store, logger)
+		go func() {
+			if err := api.ListenAndServe(*httpAddr); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	var dashboard admin.Server
+	if *adminAddr != "" {
+		dashboard = admin.NewServer(store, logger)
+		go func() {
+			if err := dashboard.ListenAndServe(*adminAddr); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	server := respserver.NewServer(store, logger)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		logger.Infoln("shutting down")
+		if api != nil {
+			_ = api.Close()
+		}
+		if dashboard != nil {
+			_ = dashboard.Close()
+		}
+		_ = server.Close()
+	}()
+
+	if err := server.ListenAndServe(*addr); err != nil {
+		log.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseSyncPolicy(policy string) (wal.SyncPolicy, error) {
+	switch policy {
+	case "always":
+		return wal.SyncEveryCommit, nil
+	case "batch":
+		return wal.SyncBatched, nil
+	case "none":
+		return wal.SyncNone, nil
+	}
+	return 0, fmt.Errorf("unknown wal sync policy %q", policy)
+}
+
+func parseEvictionPolicy(policy string) (storage.EvictionPolicy, error) {
+	switch policy {
+	case "noeviction":
+		return storage.NoEviction, nil
+	case "lru":
+		return storage.EvictLRU, nil
+	case "lfu":
+		return storage.EvictLFU, nil
+	case "random":
+		return storage.EvictRandom, nil
+	case "ttl":
+		return storage.EvictNearestTTL, nil
+	}
+	return 0, fmt.Errorf("unknown eviction policy %q", policy)
+}
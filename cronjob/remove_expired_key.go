@@ -0,0 +1,28 @@
+package cronjob
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+// RemoveExpiredKey turns expired keys into delete versions every second, reads
+// already treat them as absent in between.
+func RemoveExpiredKey(store storage.MemStorage) error {
+	c := cron.New()
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{ForceColors: true})
+
+	_, err := c.AddFunc("@every 1s", func() {
+		if err := store.RemoveExpiredKeys(context.Background()); err != nil {
+			logger.Errorln("removing expired keys has some errors:", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Start()
+	return nil
+}
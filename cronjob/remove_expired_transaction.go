@@ -0,0 +1,28 @@
+package cronjob
+
+import (
+	"context"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+// RemoveExpiredTransaction aborts the transactions past their deadline every
+// second, their own operations already fail with TxExpiredError in between.
+func RemoveExpiredTransaction(store storage.MemStorage) error {
+	c := cron.New()
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{ForceColors: true})
+
+	_, err := c.AddFunc("@every 1s", func() {
+		if err := store.RemoveExpiredTransactions(context.Background()); err != nil {
+			logger.Errorln("removing expired transactions has some errors:", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Start()
+	return nil
+}
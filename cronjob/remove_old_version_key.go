@@ -17,6 +17,10 @@ func RemoveOldVersionKey(store storage.MemStorage) error {
 			logger.Errorln("clean up old transaction has some errors: %w", err)
 		}
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	c.Start()
+	return nil
 }
@@ -11,4 +11,10 @@ func main() {
 	if err := cronjob.RemoveOldVersionKey(store); err != nil {
 		log.Fatal(err)
 	}
+	if err := cronjob.RemoveExpiredKey(store); err != nil {
+		log.Fatal(err)
+	}
+	if err := cronjob.RemoveExpiredTransaction(store); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/btree"
+)
+
+type keysResponse struct {
+	Keys []string `json:"keys"`
+	// Next is the cursor of the following page, empty on the last one.
+	Next string `json:"next"`
+}
+
+type versionResponse struct {
+	TxID      int         `json:"txId"`
+	Visible   bool        `json:"visible"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Value     interface{} `json:"value"`
+}
+
+type pendingWriteResponse struct {
+	Key     string      `json:"key"`
+	Deleted bool        `json:"deleted"`
+	Value   interface{} `json:"value"`
+}
+
+type transactionResponse struct {
+	TxID      int                    `json:"txId"`
+	StartedAt time.Time              `json:"startedAt"`
+	Age       string                 `json:"age"`
+	Writes    []pendingWriteResponse `json:"writes"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// listKeys pages through the sorted keys matching ?pattern= (default *). The
+// cursor is the last key of the previous page.
+func (s *adminServer) listKeys(w http.ResponseWriter, r *http.Request) {
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+	limit := defaultPageSize
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", raw))
+			return
+		}
+		limit = min(parsed, maxPageSize)
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid key pattern %q: %w", pattern, err))
+		return
+	}
+
+	keys, err := s.matchingKeys(r, pattern, r.URL.Query().Get("cursor"), limit+1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := keysResponse{Keys: keys}
+	if len(keys) > limit {
+		response.Keys = keys[:limit]
+		response.Next = keys[limit-1]
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// matchingKeys returns up to limit keys after cursor matching pattern. It
+// scans in order from the cursor, within the literal prefix of the pattern, so
+// a page costs about its size instead of the number of keys.
+func (s *adminServer) matchingKeys(r *http.Request, pattern, cursor string, limit int) ([]string, error) {
+	prefix := pattern
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		prefix = pattern[:i]
+	}
+	start, end := prefix, btree.PrefixEnd(prefix)
+	if cursor != "" && cursor >= start {
+		start = cursor + "\x00"
+	}
+
+	keys := make([]string, 0, limit)
+	for len(keys) < limit && (end == "" || start < end) {
+		batch, err := s.store.Scan(r.Context(), start, end, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range batch {
+			if matched, _ := path.Match(pattern, kv.Key); matched && len(keys) < limit {
+				keys = append(keys, kv.Key)
+			}
+		}
+		if len(batch) < limit {
+			break
+		}
+		start = batch[len(batch)-1].Key + "\x00"
+	}
+	return keys, nil
+}
+
+func (s *adminServer) listVersions(w http.ResponseWriter, r *http.Request) {
+	history, err := s.store.History(r.Context(), r.PathValue("key"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appCommon.KeyDoesNotExist) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	versions := make([]versionResponse, len(history))
+	for i, version := range history {
+		versions[i] = versionResponse{
+			TxID:      version.TxID,
+			Visible:   version.Visible,
+			CreatedAt: version.CreatedAt,
+			Value:     version.Value,
+		}
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (s *adminServer) listTransactions(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	transactions := s.store.Transactions(r.Context())
+
+	response := make([]transactionResponse, len(transactions))
+	for i, transaction := range transactions {
+		writes := make([]pendingWriteResponse, len(transaction.Writes))
+		for j, write := range transaction.Writes {
+			writes[j] = pendingWriteResponse{Key: write.Key, Deleted: write.Deleted, Value: write.Value}
+		}
+		response[i] = transactionResponse{
+			TxID:      transaction.TxID,
+			StartedAt: transaction.StartedAt,
+			Age:       now.Sub(transaction.StartedAt).Round(time.Millisecond).String(),
+			Writes:    writes,
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *adminServer) abortTransaction(w http.ResponseWriter, r *http.Request) {
+	txID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid transaction id %q", r.PathValue("id")))
+		return
+	}
+	if err := s.store.AbortTransaction(r.Context(), txID); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+//go:embed static
+var assets embed.FS
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Server is the web dashboard for operators: it pages through keys, shows
+// their version chains and the open transactions, and can abort a stuck one.
+// Every asset is embedded in the binary so it works offline.
+type Server interface {
+	Handler() http.Handler
+	ListenAndServe(addr string) error
+	Close() error
+}
+
+type adminServer struct {
+	store      storage.MemStorage
+	logger     *logrus.Logger
+	mux        *http.ServeMux
+	mutex      *sync.Mutex
+	httpServer *http.Server
+}
+
+func NewServer(store storage.MemStorage, logger *logrus.Logger) Server {
+	s := &adminServer{
+		store:  store,
+		logger: logger,
+		mux:    http.NewServeMux(),
+		mutex:  new(sync.Mutex),
+	}
+	s.routes()
+	return s
+}
+
+func (s *adminServer) routes() {
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		panic(err)
+	}
+	s.mux.Handle("GET /", http.FileServerFS(static))
+
+	s.mux.HandleFunc("GET /api/keys", s.listKeys)
+	s.mux.HandleFunc("GET /api/keys/{key}/versions", s.listVersions)
+	s.mux.HandleFunc("GET /api/transactions", s.listTransactions)
+	s.mux.HandleFunc("POST /api/transactions/{id}/abort", s.abortTransaction)
+}
+
+func (s *adminServer) Handler() http.Handler {
+	return s.mux
+}
+
+func (s *adminServer) ListenAndServe(addr string) error {
+	s.mutex.Lock()
+	s.httpServer = &http.Server{Addr: addr, Handler: s.mux}
+	server := s.httpServer
+	s.mutex.Unlock()
+
+	s.logger.Infof("Admin UI listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *adminServer) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Close()
+}
@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+func newTestServer(t *testing.T) (storage.MemStorage, *httptest.Server) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	store := storage.NewMemStore()
+	server := httptest.NewServer(NewServer(store, logger).Handler())
+	t.Cleanup(server.Close)
+	return store, server
+}
+
+func getJSON(t *testing.T, url string, target interface{}) int {
+	response, err := http.Get(url)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+	if target != nil {
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(target))
+	}
+	return response.StatusCode
+}
+
+func TestAdmin_ServesEmbeddedAssets(t *testing.T) {
+	_, server := newTestServer(t)
+
+	for _, path := range []string{"/", "/app.js", "/style.css"} {
+		response, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode, path)
+		_ = response.Body.Close()
+	}
+}
+
+func TestAdmin_KeysPagingAndVersions(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestServer(t)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.Set(ctx, fmt.Sprintf("user:%d", i), i))
+	}
+	assert.NoError(t, store.Set(ctx, "user:0", "updated"))
+	assert.NoError(t, store.Set(ctx, "order:1", "ignored"))
+
+	var page keysResponse
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/api/keys?pattern=user:*&limit=2", &page))
+	assert.Equal(t, []string{"user:0", "user:1"}, page.Keys)
+	assert.Equal(t, "user:1", page.Next)
+
+	var keys []string
+	for page.Next != "" {
+		keys = append(keys, page.Keys...)
+		getJSON(t, server.URL+"/api/keys?pattern=user:*&limit=2&cursor="+page.Next, &page)
+	}
+	keys = append(keys, page.Keys...)
+	assert.Equal(t, []string{"user:0", "user:1", "user:2", "user:3", "user:4"}, keys)
+
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/api/keys?pattern=*:1&limit=1", &page))
+	assert.Equal(t, []string{"order:1"}, page.Keys)
+	assert.Equal(t, "order:1", page.Next)
+	getJSON(t, server.URL+"/api/keys?pattern=*:1&limit=1&cursor="+page.Next, &page)
+	assert.Equal(t, []string{"user:1"}, page.Keys)
+	assert.Empty(t, page.Next)
+
+	var versions []versionResponse
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/api/keys/user:0/versions", &versions))
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "updated", versions[1].Value)
+	assert.True(t, versions[1].Visible)
+
+	assert.Equal(t, http.StatusNotFound, getJSON(t, server.URL+"/api/keys/missing/versions", nil))
+}
+
+func TestAdmin_TransactionsAndAbort(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestServer(t)
+
+	assert.NoError(t, store.Set(ctx, "key1", "value1"))
+	tx := store.Tx()
+	assert.NoError(t, tx.Set(ctx, "key2", "pending"))
+	assert.NoError(t, tx.Delete(ctx, "key1"))
+
+	var transactions []transactionResponse
+	assert.Equal(t, http.StatusOK, getJSON(t, server.URL+"/api/transactions", &transactions))
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, tx.ID(), transactions[0].TxID)
+	assert.Equal(t, []pendingWriteResponse{
+		{Key: "key1", Deleted: true},
+		{Key: "key2", Value: "pending"},
+	}, transactions[0].Writes)
+
+	abortURL := fmt.Sprintf("%s/api/transactions/%d/abort", server.URL, tx.ID())
+	response, err := http.Post(abortURL, "application/json", strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	_ = response.Body.Close()
+
+	assert.Error(t, tx.Commit(ctx), "an aborted transaction cannot be committed")
+	getJSON(t, server.URL+"/api/transactions", &transactions)
+	assert.Empty(t, transactions)
+
+	response, err = http.Post(abortURL, "application/json", strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	_ = response.Body.Close()
+}
@@ -0,0 +1,148 @@
+"use strict";
+
+const state = {pattern: "*", cursor: "", selectedKey: null};
+
+function showError(message) {
+    const error = document.getElementById("error");
+    error.textContent = message;
+    error.hidden = false;
+    setTimeout(() => (error.hidden = true), 4000);
+}
+
+async function api(path, options) {
+    const response = await fetch(path, options);
+    if (!response.ok) {
+        const body = await response.json().catch(() => ({error: response.statusText}));
+        throw new Error(body.error);
+    }
+    return response.status === 204 ? null : response.json();
+}
+
+function cell(row, content) {
+    const td = document.createElement("td");
+    if (content instanceof Node) {
+        td.appendChild(content);
+    } else {
+        td.textContent = content;
+    }
+    row.appendChild(td);
+    return td;
+}
+
+function code(value) {
+    const element = document.createElement("code");
+    element.textContent = JSON.stringify(value);
+    return element;
+}
+
+async function loadKeys(reset) {
+    if (reset) {
+        state.cursor = "";
+        document.getElementById("key-list").replaceChildren();
+    }
+    const query = new URLSearchParams({pattern: state.pattern, cursor: state.cursor});
+    try {
+        const page = await api(`api/keys?${query}`);
+        const list = document.getElementById("key-list");
+        for (const key of page.keys) {
+            const item = document.createElement("li");
+            item.textContent = key;
+            item.addEventListener("click", () => selectKey(key, item));
+            list.appendChild(item);
+        }
+        state.cursor = page.next;
+        document.getElementById("next-page").hidden = !page.next;
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+async function selectKey(key, item) {
+    document.querySelectorAll("#key-list li.selected").forEach((li) => li.classList.remove("selected"));
+    item.classList.add("selected");
+    state.selectedKey = key;
+    document.getElementById("version-title").textContent = `Versions of ${key}`;
+
+    try {
+        const versions = await api(`api/keys/${encodeURIComponent(key)}/versions`);
+        const body = document.querySelector("#versions tbody");
+        body.replaceChildren();
+        for (const version of versions.reverse()) {
+            const row = document.createElement("tr");
+            if (!version.visible) {
+                row.classList.add("deleted");
+            }
+            cell(row, version.txId);
+            cell(row, version.visible ? "yes" : "deleted");
+            cell(row, new Date(version.createdAt).toLocaleString());
+            cell(row, version.visible ? code(version.value) : "");
+            body.appendChild(row);
+        }
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+async function loadTransactions() {
+    try {
+        const transactions = await api("api/transactions");
+        const body = document.querySelector("#transaction-table tbody");
+        body.replaceChildren();
+        for (const transaction of transactions) {
+            const row = document.createElement("tr");
+            cell(row, transaction.txId);
+            cell(row, new Date(transaction.startedAt).toLocaleString());
+            cell(row, transaction.age);
+
+            const writes = document.createElement("ul");
+            for (const write of transaction.writes) {
+                const item = document.createElement("li");
+                item.textContent = write.deleted ? `DEL ${write.key}` : `SET ${write.key} = ${JSON.stringify(write.value)}`;
+                writes.appendChild(item);
+            }
+            cell(row, writes);
+
+            const abort = document.createElement("button");
+            abort.textContent = "Abort";
+            abort.className = "danger";
+            abort.addEventListener("click", () => abortTransaction(transaction.txId));
+            cell(row, abort);
+            body.appendChild(row);
+        }
+    } catch (error) {
+        showError(error.message);
+    }
+}
+
+async function abortTransaction(txId) {
+    if (!confirm(`Abort transaction ${txId}?`)) {
+        return;
+    }
+    try {
+        await api(`api/transactions/${txId}/abort`, {method: "POST"});
+    } catch (error) {
+        showError(error.message);
+    }
+    loadTransactions();
+}
+
+document.querySelectorAll("nav button").forEach((button) => {
+    button.addEventListener("click", () => {
+        document.querySelectorAll("nav button, .tab").forEach((element) => element.classList.remove("active"));
+        button.classList.add("active");
+        document.getElementById(button.dataset.tab).classList.add("active");
+        if (button.dataset.tab === "transactions") {
+            loadTransactions();
+        }
+    });
+});
+
+document.getElementById("key-search").addEventListener("submit", (event) => {
+    event.preventDefault();
+    state.pattern = document.getElementById("pattern").value || "*";
+    loadKeys(true);
+});
+document.getElementById("next-page").addEventListener("click", () => loadKeys(false));
+document.getElementById("refresh-transactions").addEventListener("click", loadTransactions);
+
+loadKeys(true);
@@ -0,0 +1,229 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+type keyValueResponse struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type setKeyRequest struct {
+	Value interface{} `json:"value"`
+}
+
+type txResponse struct {
+	ID int `json:"id"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeStoreError maps engine errors to HTTP status codes.
+func writeStoreError(w http.ResponseWriter, err error) {
+	var (
+		conflict   *appCommon.TxConflictError
+		mismatch   *appCommon.VersionMismatchError
+		expired    *appCommon.TxExpiredError
+		locked     *appCommon.KeyLockedError
+		readOnly   *appCommon.ReadOnlyTxError
+		uniqueness *appCommon.UniqueViolationError
+	)
+	switch {
+	case errors.Is(err, appCommon.KeyDoesNotExist):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &conflict), errors.As(err, &uniqueness):
+		writeError(w, http.StatusConflict, err)
+	case errors.As(err, &mismatch):
+		writeError(w, http.StatusPreconditionFailed, err)
+	case errors.As(err, &expired):
+		writeError(w, http.StatusGone, err)
+	case errors.As(err, &locked):
+		writeError(w, http.StatusLocked, err)
+	case errors.As(err, &readOnly):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, appCommon.OutOfMemory):
+		writeError(w, http.StatusInsufficientStorage, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// maxBodySize caps the request body, larger values are rejected before they
+// are decoded.
+const maxBodySize = 1 << 20
+
+func decodeValue(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var body setKeyRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	return body.Value, nil
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
+}
+
+type keyGetter interface {
+	Get(ctx context.Context, key string) (interface{}, error)
+}
+
+func (s *httpServer) writeKey(w http.ResponseWriter, r *http.Request, target keyGetter) {
+	key := r.PathValue("key")
+	value, err := target.Get(r.Context(), key)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if value == nil {
+		writeStoreError(w, appCommon.KeyDoesNotExist)
+		return
+	}
+	writeJSON(w, http.StatusOK, keyValueResponse{Key: key, Value: value})
+}
+
+func (s *httpServer) getKey(w http.ResponseWriter, r *http.Request) {
+	s.writeKey(w, r, s.store)
+}
+
+func (s *httpServer) setKey(w http.ResponseWriter, r *http.Request) {
+	value, err := decodeValue(w, r)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if err := s.store.Set(r.Context(), r.PathValue("key"), value); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) deleteKey(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.Delete(r.Context(), r.PathValue("key")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) beginTx(w http.ResponseWriter, r *http.Request) {
+	// the session reaper expires idle sessions, a fixed deadline would also
+	// end the ones still in use
+	tx := s.store.Tx(storage.WithTimeout(0))
+	s.addSession(tx)
+	writeJSON(w, http.StatusCreated, txResponse{ID: tx.ID()})
+}
+
+// txFromPath resolves the {id} path segment to an open session, writing the
+// error response itself when there is none.
+func (s *httpServer) txFromPath(w http.ResponseWriter, r *http.Request, end bool) (storage.MemTx, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid transaction id %q", r.PathValue("id")))
+		return nil, false
+	}
+
+	var tx storage.MemTx
+	var ok bool
+	if end {
+		tx, ok = s.endSession(id)
+	} else {
+		tx, ok = s.session(id)
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, appCommon.NewTxIDDoesNotExistError(id))
+		return nil, false
+	}
+	return tx, true
+}
+
+func (s *httpServer) getTxKey(w http.ResponseWriter, r *http.Request) {
+	tx, ok := s.txFromPath(w, r, false)
+	if !ok {
+		return
+	}
+	s.writeKey(w, r, tx)
+}
+
+func (s *httpServer) setTxKey(w http.ResponseWriter, r *http.Request) {
+	tx, ok := s.txFromPath(w, r, false)
+	if !ok {
+		return
+	}
+	value, err := decodeValue(w, r)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if err := tx.Set(r.Context(), r.PathValue("key"), value); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) deleteTxKey(w http.ResponseWriter, r *http.Request) {
+	tx, ok := s.txFromPath(w, r, false)
+	if !ok {
+		return
+	}
+	if err := tx.Delete(r.Context(), r.PathValue("key")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) commitTx(w http.ResponseWriter, r *http.Request) {
+	tx, ok := s.txFromPath(w, r, true)
+	if !ok {
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		// a transaction that failed to commit cannot be retried as is
+		_ = tx.Abort(r.Context())
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) abortTx(w http.ResponseWriter, r *http.Request) {
+	tx, ok := s.txFromPath(w, r, true)
+	if !ok {
+		return
+	}
+	if err := tx.Abort(r.Context()); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
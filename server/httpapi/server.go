@@ -0,0 +1,188 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+// Server exposes a MemStorage as a REST API. Transactions live in server side
+// sessions addressed by their txID, sessions that are not touched for
+// appCommon.TransactionTimeout are aborted by a background reaper.
+type Server interface {
+	Handler() http.Handler
+	ListenAndServe(addr string) error
+	Close() error
+}
+
+// readHeaderTimeout and readTimeout stop slow clients from holding
+// connections open while they trickle in a request.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+)
+
+type txSession struct {
+	tx       storage.MemTx
+	lastUsed time.Time
+}
+
+type httpServer struct {
+	store          storage.MemStorage
+	logger         *logrus.Logger
+	mux            *http.ServeMux
+	httpServer     *http.Server
+	mutex          *sync.Mutex
+	sessions       map[int]*txSession
+	sessionTimeout time.Duration
+	done           chan struct{}
+	closeOnce      *sync.Once
+	wg             *sync.WaitGroup
+}
+
+func NewServer(store storage.MemStorage, logger *logrus.Logger) Server {
+	return newServer(store, logger, appCommon.TransactionTimeout)
+}
+
+func newServer(store storage.MemStorage, logger *logrus.Logger, sessionTimeout time.Duration) *httpServer {
+	s := &httpServer{
+		store:          store,
+		logger:         logger,
+		mux:            http.NewServeMux(),
+		mutex:          new(sync.Mutex),
+		sessions:       make(map[int]*txSession),
+		sessionTimeout: sessionTimeout,
+		done:           make(chan struct{}),
+		closeOnce:      new(sync.Once),
+		wg:             new(sync.WaitGroup),
+	}
+	s.routes()
+
+	s.wg.Add(1)
+	go s.reapSessions()
+	return s
+}
+
+func (s *httpServer) routes() {
+	s.mux.HandleFunc("GET /keys/{key}", s.getKey)
+	s.mux.HandleFunc("PUT /keys/{key}", s.setKey)
+	s.mux.HandleFunc("DELETE /keys/{key}", s.deleteKey)
+
+	s.mux.HandleFunc("POST /tx", s.beginTx)
+	s.mux.HandleFunc("GET /tx/{id}/keys/{key}", s.getTxKey)
+	s.mux.HandleFunc("PUT /tx/{id}/keys/{key}", s.setTxKey)
+	s.mux.HandleFunc("DELETE /tx/{id}/keys/{key}", s.deleteTxKey)
+	s.mux.HandleFunc("POST /tx/{id}/commit", s.commitTx)
+	s.mux.HandleFunc("POST /tx/{id}/abort", s.abortTx)
+}
+
+func (s *httpServer) Handler() http.Handler {
+	return s.mux
+}
+
+func (s *httpServer) ListenAndServe(addr string) error {
+	s.mutex.Lock()
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+	}
+	server := s.httpServer
+	s.mutex.Unlock()
+
+	s.logger.Infof("HTTP API listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Close stops the listener (if any), the reaper and aborts every open session.
+// Closing a closed server does nothing.
+func (s *httpServer) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.wg.Wait()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var err error
+	if s.httpServer != nil {
+		err = s.httpServer.Close()
+	}
+	for id, session := range s.sessions {
+		_ = session.tx.Abort(context.Background())
+		delete(s.sessions, id)
+	}
+	return err
+}
+
+func (s *httpServer) reapSessions() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.sessionTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reapExpiredSessions(time.Now())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *httpServer) reapExpiredSessions(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, session := range s.sessions {
+		if now.Sub(session.lastUsed) < s.sessionTimeout {
+			continue
+		}
+		s.logger.Infof("Aborting abandoned transaction %d", id)
+		_ = session.tx.Abort(context.Background())
+		delete(s.sessions, id)
+	}
+}
+
+func (s *httpServer) addSession(tx storage.MemTx) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sessions[tx.ID()] = &txSession{tx: tx, lastUsed: time.Now()}
+}
+
+// session returns the transaction of an open session and refreshes its idle
+// timer.
+func (s *httpServer) session(id int) (storage.MemTx, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	session.lastUsed = time.Now()
+	return session.tx, true
+}
+
+// endSession removes the session, the caller commits or aborts its transaction.
+func (s *httpServer) endSession(id int) (storage.MemTx, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	delete(s.sessions, id)
+	return session.tx, true
+}
@@ -0,0 +1,193 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+func newTestServer(t *testing.T, sessionTimeout time.Duration) (*httpServer, *httptest.Server) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	server := newServer(storage.NewMemStore(), logger, sessionTimeout)
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(func() {
+		httpServer.Close()
+		_ = server.Close()
+	})
+	return server, httpServer
+}
+
+func do(t *testing.T, method, url, body string) (int, map[string]interface{}) {
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	var decoded map[string]interface{}
+	_ = json.NewDecoder(response.Body).Decode(&decoded)
+	return response.StatusCode, decoded
+}
+
+func TestServer_KeyCRUD(t *testing.T) {
+	_, server := newTestServer(t, time.Minute)
+
+	status, _ := do(t, http.MethodPut, server.URL+"/keys/key1", `{"value": "value1"}`)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, body := do(t, http.MethodGet, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "value1", body["value"])
+
+	status, _ = do(t, http.MethodPut, server.URL+"/keys/key1", `not json`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = do(t, http.MethodDelete, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = do(t, http.MethodGet, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = do(t, http.MethodDelete, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_TransactionSessions(t *testing.T) {
+	_, server := newTestServer(t, time.Minute)
+
+	status, body := do(t, http.MethodPost, server.URL+"/tx", "")
+	assert.Equal(t, http.StatusCreated, status)
+	txURL := fmt.Sprintf("%s/tx/%v", server.URL, body["id"])
+
+	status, _ = do(t, http.MethodPut, txURL+"/keys/key1", `{"value": 1}`)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, body = do(t, http.MethodGet, txURL+"/keys/key1", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), body["value"])
+
+	status, _ = do(t, http.MethodGet, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = do(t, http.MethodPost, txURL+"/commit", "")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = do(t, http.MethodGet, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusOK, status)
+
+	// the session is gone after commit
+	status, _ = do(t, http.MethodGet, txURL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_CommitConflict(t *testing.T) {
+	_, server := newTestServer(t, time.Minute)
+
+	_, body := do(t, http.MethodPost, server.URL+"/tx", "")
+	txURL := fmt.Sprintf("%s/tx/%v", server.URL, body["id"])
+
+	status, _ := do(t, http.MethodPut, txURL+"/keys/key1", `{"value": "tx"}`)
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = do(t, http.MethodPut, server.URL+"/keys/key1", `{"value": "direct"}`)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = do(t, http.MethodPost, txURL+"/commit", "")
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, body = do(t, http.MethodGet, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "direct", body["value"])
+}
+
+func TestServer_AbortAndReap(t *testing.T) {
+	api, server := newTestServer(t, time.Minute)
+
+	_, body := do(t, http.MethodPost, server.URL+"/tx", "")
+	txURL := fmt.Sprintf("%s/tx/%v", server.URL, body["id"])
+	status, _ := do(t, http.MethodPost, txURL+"/abort", "")
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = do(t, http.MethodPost, txURL+"/commit", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, body = do(t, http.MethodPost, server.URL+"/tx", "")
+	txURL = fmt.Sprintf("%s/tx/%v", server.URL, body["id"])
+	api.reapExpiredSessions(time.Now().Add(2 * time.Minute))
+
+	status, _ = do(t, http.MethodGet, txURL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestWriteStoreError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{appCommon.KeyDoesNotExist, http.StatusNotFound},
+		{appCommon.NewTxIDCanNotBeCommited(1), http.StatusConflict},
+		{appCommon.NewUniqueViolationError("email", "key1", "key2"), http.StatusConflict},
+		{appCommon.NewVersionMismatchError("key1", 1, 2), http.StatusPreconditionFailed},
+		{appCommon.NewTxExpiredError(1), http.StatusGone},
+		{appCommon.NewKeyLockedError("key1", 1, nil), http.StatusLocked},
+		{appCommon.NewReadOnlyTxError(1), http.StatusBadRequest},
+		{fmt.Errorf("commit: %w", appCommon.OutOfMemory), http.StatusInsufficientStorage},
+		{fmt.Errorf("boom"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		writeStoreError(recorder, c.err)
+		assert.Equal(t, c.status, recorder.Code, c.err.Error())
+	}
+}
+
+func TestServer_CloseTwice(t *testing.T) {
+	api, _ := newTestServer(t, time.Minute)
+
+	assert.NoError(t, api.Close())
+	assert.NoError(t, api.Close())
+}
+
+func TestServer_ActiveSessionOutlivesTimeout(t *testing.T) {
+	api, server := newTestServer(t, 50*time.Millisecond)
+
+	_, body := do(t, http.MethodPost, server.URL+"/tx", "")
+	txURL := fmt.Sprintf("%s/tx/%v", server.URL, body["id"])
+
+	// only the idle timeout of the session applies, not a fixed deadline
+	transactions := api.store.Transactions(context.Background())
+	assert.Len(t, transactions, 1)
+	assert.Zero(t, transactions[0].Deadline)
+
+	for i := 0; i < 6; i++ {
+		status, _ := do(t, http.MethodPut, fmt.Sprintf("%s/keys/key%d", txURL, i), `{"value": "v"}`)
+		assert.Equal(t, http.StatusNoContent, status)
+		time.Sleep(20 * time.Millisecond)
+	}
+	status, _ := do(t, http.MethodPost, txURL+"/commit", "")
+	assert.Equal(t, http.StatusNoContent, status)
+}
+
+func TestServer_BodyTooLarge(t *testing.T) {
+	_, server := newTestServer(t, time.Minute)
+
+	value := strings.Repeat("a", maxBodySize)
+	status, _ := do(t, http.MethodPut, server.URL+"/keys/key1", fmt.Sprintf(`{"value": %q}`, value))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	status, _ = do(t, http.MethodGet, server.URL+"/keys/key1", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = do(t, http.MethodPut, server.URL+"/keys/key1", `{"value": `)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,46 @@
+package resp
+
+import (
+	"net"
+	"sync"
+)
+
+// Client is a minimal synchronous RESP client: one request, one reply.
+type Client struct {
+	mutex  *sync.Mutex
+	conn   net.Conn
+	reader *Reader
+	writer *Writer
+}
+
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		mutex:  new(sync.Mutex),
+		conn:   conn,
+		reader: NewReader(conn),
+		writer: NewWriter(conn),
+	}, nil
+}
+
+// Do sends one command and waits for its reply. Error replies are returned as
+// values of type Error, not as Go errors.
+func (c *Client) Do(args ...string) (Value, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.writer.WriteCommand(args...); err != nil {
+		return Value{}, err
+	}
+	if err := c.writer.Flush(); err != nil {
+		return Value{}, err
+	}
+	return c.reader.ReadValue()
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,145 @@
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Limits on what a peer may announce, as in Redis, so a header alone cannot
+// make us allocate unbounded memory or recurse without end.
+const (
+	maxBulkLength      = 512 * 1024 * 1024
+	maxMultibulkLength = 1024 * 1024
+	maxNestingDepth    = 32
+)
+
+type Reader struct {
+	reader *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{reader: bufio.NewReader(r)}
+}
+
+// ReadCommand reads one client request. Both the array form sent by client
+// libraries and the inline form typed into telnet are accepted. An empty
+// inline line yields an empty command.
+func (r *Reader) ReadCommand() ([]string, error) {
+	prefix, err := r.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix[0] != '*' {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Fields(line), nil
+	}
+
+	value, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(value.Elems))
+	for i, elem := range value.Elems {
+		if elem.Type != BulkString && elem.Type != SimpleString {
+			return nil, fmt.Errorf("protocol error: expected bulk string, got '%c'", elem.Type)
+		}
+		args[i] = elem.Str
+	}
+	return args, nil
+}
+
+// ReadValue reads one RESP2 or RESP3 value, used by clients to parse replies.
+func (r *Reader) ReadValue() (Value, error) {
+	return r.readValue(0)
+}
+
+func (r *Reader) readValue(depth int) (Value, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+	if len(line) == 0 {
+		return Value{}, fmt.Errorf("protocol error: empty line")
+	}
+
+	t, payload := Type(line[0]), line[1:]
+	switch t {
+	case SimpleString, Error, Double:
+		return Value{Type: t, Str: payload}, nil
+	case Integer:
+		i, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("protocol error: invalid integer %q", payload)
+		}
+		return NewInteger(i), nil
+	case Boolean:
+		if payload == "t" {
+			return Value{Type: Boolean, Int: 1}, nil
+		}
+		return Value{Type: Boolean}, nil
+	case Null:
+		return Value{Type: Null, IsNull: true}, nil
+	case BulkString:
+		return r.readBulk(payload)
+	case Array, Set, Push, Map:
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return Value{}, fmt.Errorf("protocol error: invalid length %q", payload)
+		}
+		if size < 0 {
+			return Value{Type: t, IsNull: true}, nil
+		}
+		if size > maxMultibulkLength {
+			return Value{}, fmt.Errorf("protocol error: invalid multibulk length")
+		}
+		if depth >= maxNestingDepth {
+			return Value{}, fmt.Errorf("protocol error: too deeply nested")
+		}
+		if t == Map {
+			size *= 2
+		}
+		// grow with what actually arrives instead of trusting the header
+		elems := make([]Value, 0, min(size, 1024))
+		for i := 0; i < size; i++ {
+			elem, err := r.readValue(depth + 1)
+			if err != nil {
+				return Value{}, err
+			}
+			elems = append(elems, elem)
+		}
+		return Value{Type: t, Elems: elems}, nil
+	default:
+		return Value{}, fmt.Errorf("protocol error: unexpected type '%c'", t)
+	}
+}
+
+func (r *Reader) readBulk(payload string) (Value, error) {
+	size, err := strconv.Atoi(payload)
+	if err != nil || size > maxBulkLength {
+		return Value{}, fmt.Errorf("protocol error: invalid bulk length %q", payload)
+	}
+	if size < 0 {
+		return NullBulkString(), nil
+	}
+
+	buffer := make([]byte, size+2)
+	if _, err := io.ReadFull(r.reader, buffer); err != nil {
+		return Value{}, err
+	}
+	return NewBulkString(string(buffer[:size])), nil
+}
+
+func (r *Reader) readLine() (string, error) {
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,84 @@
+package resp_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/server/resp"
+)
+
+func TestReader_ReadCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{"array command", "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n", []string{"SET", "key", "value"}},
+		{"inline command", "GET key\r\n", []string{"GET", "key"}},
+		{"empty bulk argument", "*2\r\n$3\r\nGET\r\n$0\r\n\r\n", []string{"GET", ""}},
+		{"binary safe argument", "*2\r\n$4\r\nECHO\r\n$4\r\na\r\nb\r\n", []string{"ECHO", "a\r\nb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := resp.NewReader(strings.NewReader(tt.input)).ReadCommand()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, args)
+		})
+	}
+}
+
+func TestReader_Limits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"oversized array", "*2000000000\r\n"},
+		{"oversized map", "%4611686018427387904\r\n"},
+		{"oversized bulk", "$2000000000\r\n"},
+		{"deep nesting", strings.Repeat("*1\r\n", 100) + ":1\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resp.NewReader(strings.NewReader(tt.input)).ReadValue()
+			assert.ErrorContains(t, err, "protocol error")
+		})
+	}
+}
+
+func TestWriter_Protocols(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol int
+		value    resp.Value
+		expect   string
+	}{
+		{"simple string", 2, resp.OK(), "+OK\r\n"},
+		{"error", 2, resp.NewError("ERR boom"), "-ERR boom\r\n"},
+		{"integer", 2, resp.NewInteger(42), ":42\r\n"},
+		{"bulk string", 2, resp.NewBulkString("value"), "$5\r\nvalue\r\n"},
+		{"null bulk resp2", 2, resp.NullBulkString(), "$-1\r\n"},
+		{"null array resp2", 2, resp.NullArray(), "*-1\r\n"},
+		{"null resp3", 3, resp.NullBulkString(), "_\r\n"},
+		{"map resp2", 2, resp.NewMap(resp.NewBulkString("a"), resp.NewInteger(1)), "*2\r\n$1\r\na\r\n:1\r\n"},
+		{"map resp3", 3, resp.NewMap(resp.NewBulkString("a"), resp.NewInteger(1)), "%1\r\n$1\r\na\r\n:1\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := new(bytes.Buffer)
+			writer := resp.NewWriter(buffer)
+			writer.SetProtocol(tt.protocol)
+			assert.NoError(t, writer.WriteValue(tt.value))
+			assert.NoError(t, writer.Flush())
+			assert.Equal(t, tt.expect, buffer.String())
+
+			value, err := resp.NewReader(buffer).ReadValue()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value.String(), value.String())
+		})
+	}
+}
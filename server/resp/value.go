@@ -0,0 +1,82 @@
+package resp
+
+import "strconv"
+
+type Type byte
+
+const (
+	SimpleString Type = '+'
+	Error        Type = '-'
+	Integer      Type = ':'
+	BulkString   Type = '$'
+	Array        Type = '*'
+	Null         Type = '_'
+	Boolean      Type = '#'
+	Double       Type = ','
+	Map          Type = '%'
+	Set          Type = '~'
+	Push         Type = '>'
+)
+
+// Value is a single RESP2/RESP3 value. Elems holds the elements of arrays,
+// sets and pushes, and the flattened key/value pairs of maps. IsNull marks the
+// RESP2 null bulk string ($-1) and null array (*-1).
+type Value struct {
+	Type   Type
+	Str    string
+	Int    int64
+	Elems  []Value
+	IsNull bool
+}
+
+func NewSimpleString(s string) Value {
+	return Value{Type: SimpleString, Str: s}
+}
+
+func NewError(message string) Value {
+	return Value{Type: Error, Str: message}
+}
+
+func NewInteger(i int64) Value {
+	return Value{Type: Integer, Int: i}
+}
+
+func NewBulkString(s string) Value {
+	return Value{Type: BulkString, Str: s}
+}
+
+func NewArray(elems ...Value) Value {
+	return Value{Type: Array, Elems: elems}
+}
+
+// NewMap builds a map reply from alternating keys and values. It is sent as a
+// flat array to RESP2 clients.
+func NewMap(pairs ...Value) Value {
+	return Value{Type: Map, Elems: pairs}
+}
+
+func NullBulkString() Value {
+	return Value{Type: BulkString, IsNull: true}
+}
+
+func NullArray() Value {
+	return Value{Type: Array, IsNull: true}
+}
+
+func OK() Value {
+	return NewSimpleString("OK")
+}
+
+// String renders the value the way redis-cli would print a scalar reply.
+func (v Value) String() string {
+	switch v.Type {
+	case Integer:
+		return strconv.FormatInt(v.Int, 10)
+	case Null:
+		return "(nil)"
+	}
+	if v.IsNull {
+		return "(nil)"
+	}
+	return v.Str
+}
@@ -0,0 +1,122 @@
+package resp
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// Writer encodes values for one connection. Protocol 2 is the default, HELLO 3
+// switches the connection to RESP3 which has native null and map types.
+type Writer struct {
+	writer   *bufio.Writer
+	protocol int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		writer:   bufio.NewWriter(w),
+		protocol: 2,
+	}
+}
+
+func (w *Writer) SetProtocol(protocol int) {
+	w.protocol = protocol
+}
+
+func (w *Writer) Protocol() int {
+	return w.protocol
+}
+
+func (w *Writer) Flush() error {
+	return w.writer.Flush()
+}
+
+// WriteCommand encodes a client request as an array of bulk strings.
+func (w *Writer) WriteCommand(args ...string) error {
+	elems := make([]Value, len(args))
+	for i, arg := range args {
+		elems[i] = NewBulkString(arg)
+	}
+	return w.WriteValue(NewArray(elems...))
+}
+
+func (w *Writer) WriteValue(v Value) error {
+	if v.IsNull || v.Type == Null {
+		return w.writeNull(v.Type)
+	}
+
+	switch v.Type {
+	case SimpleString, Error:
+		return w.writeLine(byte(v.Type), v.Str)
+	case Integer:
+		return w.writeLine(':', strconv.FormatInt(v.Int, 10))
+	case BulkString:
+		if err := w.writeLine('$', strconv.Itoa(len(v.Str))); err != nil {
+			return err
+		}
+		if _, err := w.writer.WriteString(v.Str); err != nil {
+			return err
+		}
+		_, err := w.writer.WriteString("\r\n")
+		return err
+	case Boolean:
+		if w.protocol < 3 {
+			return w.writeLine(':', strconv.FormatInt(v.Int, 10))
+		}
+		if v.Int != 0 {
+			return w.writeLine('#', "t")
+		}
+		return w.writeLine('#', "f")
+	case Double:
+		if w.protocol < 3 {
+			return w.WriteValue(NewBulkString(v.Str))
+		}
+		return w.writeLine(',', v.Str)
+	case Map:
+		if w.protocol < 3 {
+			return w.writeAggregate('*', v.Elems, len(v.Elems))
+		}
+		return w.writeAggregate('%', v.Elems, len(v.Elems)/2)
+	case Set, Push:
+		if w.protocol < 3 {
+			return w.writeAggregate('*', v.Elems, len(v.Elems))
+		}
+		return w.writeAggregate(byte(v.Type), v.Elems, len(v.Elems))
+	default:
+		return w.writeAggregate('*', v.Elems, len(v.Elems))
+	}
+}
+
+func (w *Writer) writeNull(t Type) error {
+	if w.protocol >= 3 {
+		return w.writeLine('_', "")
+	}
+	if t == Array {
+		return w.writeLine('*', "-1")
+	}
+	return w.writeLine('$', "-1")
+}
+
+func (w *Writer) writeAggregate(prefix byte, elems []Value, size int) error {
+	if err := w.writeLine(prefix, strconv.Itoa(size)); err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		if err := w.WriteValue(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) writeLine(prefix byte, line string) error {
+	if err := w.writer.WriteByte(prefix); err != nil {
+		return err
+	}
+	if _, err := w.writer.WriteString(line); err != nil {
+		return err
+	}
+	_, err := w.writer.WriteString("\r\n")
+	return err
+}
@@ -0,0 +1,255 @@
+package respserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/server/resp"
+	"in-memory-storage-engine/storage_engine/version"
+)
+
+// keyValue is the part of the API shared by MemStorage and MemTx, so every
+// data command runs the same way inside and outside MULTI/EXEC.
+type keyValue interface {
+	Set(ctx context.Context, key string, value interface{}) error
+	Get(ctx context.Context, key string) (interface{}, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// inspector is implemented by MemStorage only, commands using it are not
+// available inside MULTI.
+type inspector interface {
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	History(ctx context.Context, key string) ([]version.VersionInfo, error)
+}
+
+type command struct {
+	// arity follows the Redis convention: positive means exactly that many
+	// arguments (command name included), negative means at least -arity.
+	arity   int
+	handler func(ctx context.Context, target keyValue, args []string) resp.Value
+}
+
+func (c command) checkArity(argc int) bool {
+	if c.arity >= 0 {
+		return argc == c.arity
+	}
+	return argc >= -c.arity
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"PING":    {arity: -1, handler: ping},
+		"ECHO":    {arity: 2, handler: echo},
+		"SELECT":  {arity: 2, handler: selectDB},
+		"COMMAND": {arity: -1, handler: commandInfo},
+		"CLIENT":  {arity: -2, handler: client},
+		"GET":     {arity: 2, handler: get},
+		"SET":     {arity: -3, handler: set},
+		"DEL":     {arity: -2, handler: del},
+		"EXISTS":  {arity: -2, handler: exists},
+		"KEYS":    {arity: 2, handler: keys},
+		"HISTORY": {arity: 2, handler: history},
+	}
+}
+
+func execute(ctx context.Context, target keyValue, args []string) resp.Value {
+	cmd, ok := commands[strings.ToUpper(args[0])]
+	if !ok {
+		return unknownCommand(args[0])
+	}
+	if !cmd.checkArity(len(args)) {
+		return wrongArity(args[0])
+	}
+	return cmd.handler(ctx, target, args)
+}
+
+func unknownCommand(name string) resp.Value {
+	return resp.NewError(fmt.Sprintf("ERR unknown command '%s'", name))
+}
+
+func wrongArity(name string) resp.Value {
+	return resp.NewError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+func syntaxError() resp.Value {
+	return resp.NewError("ERR syntax error")
+}
+
+func isConflict(err error) bool {
+	var conflict *appCommon.TxConflictError
+	return errors.As(err, &conflict)
+}
+
+func errorReply(err error) resp.Value {
+	if isConflict(err) {
+		return resp.NewError("CONFLICT " + err.Error())
+	}
+	return resp.NewError("ERR " + err.Error())
+}
+
+// encodeValue renders a stored value as a bulk string. Values written through
+// this server are strings already, anything else is formatted with fmt.
+func encodeValue(value interface{}) resp.Value {
+	switch v := value.(type) {
+	case nil:
+		return resp.NullBulkString()
+	case string:
+		return resp.NewBulkString(v)
+	case []byte:
+		return resp.NewBulkString(string(v))
+	default:
+		return resp.NewBulkString(fmt.Sprint(v))
+	}
+}
+
+func hello(writer *resp.Writer, args []string) resp.Value {
+	if len(args) > 1 {
+		protocol, err := strconv.Atoi(args[1])
+		if err != nil || protocol < 2 || protocol > 3 {
+			return resp.NewError("NOPROTO unsupported protocol version")
+		}
+		writer.SetProtocol(protocol)
+	}
+
+	return resp.NewMap(
+		resp.NewBulkString("server"), resp.NewBulkString("in-memory-storage-engine"),
+		resp.NewBulkString("version"), resp.NewBulkString("7.0.0"),
+		resp.NewBulkString("proto"), resp.NewInteger(int64(writer.Protocol())),
+		resp.NewBulkString("mode"), resp.NewBulkString("standalone"),
+		resp.NewBulkString("role"), resp.NewBulkString("master"),
+		resp.NewBulkString("modules"), resp.NewArray(),
+	)
+}
+
+func ping(ctx context.Context, target keyValue, args []string) resp.Value {
+	if len(args) > 1 {
+		return resp.NewBulkString(args[1])
+	}
+	return resp.NewSimpleString("PONG")
+}
+
+func echo(ctx context.Context, target keyValue, args []string) resp.Value {
+	return resp.NewBulkString(args[1])
+}
+
+func selectDB(ctx context.Context, target keyValue, args []string) resp.Value {
+	if args[1] != "0" {
+		return resp.NewError("ERR DB index is out of range")
+	}
+	return resp.OK()
+}
+
+// commandInfo answers the COMMAND introspection calls redis-cli makes on
+// start, we do not describe our commands.
+func commandInfo(ctx context.Context, target keyValue, args []string) resp.Value {
+	return resp.NewArray()
+}
+
+// client accepts CLIENT SETNAME / SETINFO sent by client libraries on connect.
+func client(ctx context.Context, target keyValue, args []string) resp.Value {
+	return resp.OK()
+}
+
+func get(ctx context.Context, target keyValue, args []string) resp.Value {
+	value, err := target.Get(ctx, args[1])
+	if err != nil {
+		if errors.Is(err, appCommon.KeyDoesNotExist) {
+			return resp.NullBulkString()
+		}
+		return errorReply(err)
+	}
+	return encodeValue(value)
+}
+
+func set(ctx context.Context, target keyValue, args []string) resp.Value {
+	if len(args) != 3 {
+		return syntaxError()
+	}
+	if err := target.Set(ctx, args[1], args[2]); err != nil {
+		return errorReply(err)
+	}
+	return resp.OK()
+}
+
+func del(ctx context.Context, target keyValue, args []string) resp.Value {
+	var deleted int64
+	for _, key := range args[1:] {
+		err := target.Delete(ctx, key)
+		if err == nil {
+			deleted++
+			continue
+		}
+		if !errors.Is(err, appCommon.KeyDoesNotExist) {
+			return errorReply(err)
+		}
+	}
+	return resp.NewInteger(deleted)
+}
+
+func exists(ctx context.Context, target keyValue, args []string) resp.Value {
+	var found int64
+	for _, key := range args[1:] {
+		value, err := target.Get(ctx, key)
+		if err != nil && !errors.Is(err, appCommon.KeyDoesNotExist) {
+			return errorReply(err)
+		}
+		if value != nil {
+			found++
+		}
+	}
+	return resp.NewInteger(found)
+}
+
+func keys(ctx context.Context, target keyValue, args []string) resp.Value {
+	store, ok := target.(inspector)
+	if !ok {
+		return resp.NewError("ERR KEYS is not supported inside MULTI")
+	}
+	matched, err := store.Keys(ctx, args[1])
+	if err != nil {
+		return errorReply(err)
+	}
+	elems := make([]resp.Value, len(matched))
+	for i, key := range matched {
+		elems[i] = resp.NewBulkString(key)
+	}
+	return resp.NewArray(elems...)
+}
+
+// history replies with one [txID, visible, createdAt, value] array per version.
+func history(ctx context.Context, target keyValue, args []string) resp.Value {
+	store, ok := target.(inspector)
+	if !ok {
+		return resp.NewError("ERR HISTORY is not supported inside MULTI")
+	}
+	versions, err := store.History(ctx, args[1])
+	if err != nil {
+		if errors.Is(err, appCommon.KeyDoesNotExist) {
+			return resp.NewArray()
+		}
+		return errorReply(err)
+	}
+
+	elems := make([]resp.Value, len(versions))
+	for i, version := range versions {
+		visible := int64(0)
+		if version.Visible {
+			visible = 1
+		}
+		elems[i] = resp.NewArray(
+			resp.NewInteger(int64(version.TxID)),
+			resp.NewInteger(visible),
+			resp.NewBulkString(version.CreatedAt.Format(time.RFC3339Nano)),
+			encodeValue(version.Value),
+		)
+	}
+	return resp.NewArray(elems...)
+}
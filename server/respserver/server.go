@@ -0,0 +1,133 @@
+package respserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"in-memory-storage-engine/server/resp"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+// Server serves a MemStorage over the Redis protocol (RESP2, or RESP3 after
+// HELLO 3) so redis-cli and standard Redis client libraries can talk to it.
+type Server interface {
+	ListenAndServe(addr string) error
+	Serve(listener net.Listener) error
+	Close() error
+}
+
+type respServer struct {
+	store    storage.MemStorage
+	logger   *logrus.Logger
+	mutex    *sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       *sync.WaitGroup
+	closed   bool
+}
+
+func NewServer(store storage.MemStorage, logger *logrus.Logger) Server {
+	return &respServer{
+		store:  store,
+		logger: logger,
+		mutex:  new(sync.Mutex),
+		conns:  make(map[net.Conn]struct{}),
+		wg:     new(sync.WaitGroup),
+	}
+}
+
+func (s *respServer) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+func (s *respServer) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.mutex.Unlock()
+
+	s.logger.Infof("RESP server listening on %s", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		s.mutex.Lock()
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+func (s *respServer) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		_ = conn.Close()
+	}()
+
+	ctx := context.Background()
+	reader := resp.NewReader(conn)
+	writer := resp.NewWriter(conn)
+	session := newSession(s.store)
+
+	for {
+		args, err := reader.ReadCommand()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.Errorln(err)
+				_ = writer.WriteValue(resp.NewError("ERR " + err.Error()))
+				_ = writer.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		reply, quit := session.handle(ctx, writer, args)
+		if err := writer.WriteValue(reply); err != nil {
+			return
+		}
+		if err := writer.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// Close stops accepting connections and closes the open ones. Transactions of
+// closed connections are aborted.
+func (s *respServer) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	return err
+}
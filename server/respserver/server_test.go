@@ -0,0 +1,189 @@
+package respserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/server/resp"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *resp.Reader
+	writer *resp.Writer
+}
+
+func (c *testClient) do(args ...string) resp.Value {
+	assert.NoError(c.t, c.writer.WriteCommand(args...))
+	assert.NoError(c.t, c.writer.Flush())
+	value, err := c.reader.ReadValue()
+	assert.NoError(c.t, err)
+	return value
+}
+
+func startServer(t *testing.T) (storage.MemStorage, func() *testClient) {
+	store := storage.NewMemStore()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	server := NewServer(store, logger)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return store, func() *testClient {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return &testClient{t: t, conn: conn, reader: resp.NewReader(conn), writer: resp.NewWriter(conn)}
+	}
+}
+
+func TestServer_SimpleCommands(t *testing.T) {
+	_, connect := startServer(t)
+	client := connect()
+
+	assert.Equal(t, "PONG", client.do("PING").Str)
+	assert.Equal(t, "OK", client.do("SET", "key1", "value1").Str)
+	assert.Equal(t, "value1", client.do("GET", "key1").Str)
+	assert.True(t, client.do("GET", "missing").IsNull)
+	assert.Equal(t, int64(1), client.do("EXISTS", "key1", "missing").Int)
+	assert.Equal(t, int64(1), client.do("DEL", "key1", "missing").Int)
+	assert.True(t, client.do("GET", "key1").IsNull)
+	assert.Equal(t, resp.Error, client.do("GET").Type)
+	assert.Equal(t, resp.Error, client.do("NOPE").Type)
+}
+
+func TestServer_Hello3(t *testing.T) {
+	_, connect := startServer(t)
+	client := connect()
+
+	hello := client.do("HELLO", "3")
+	assert.Equal(t, resp.Map, hello.Type)
+	assert.Equal(t, resp.Null, client.do("GET", "missing").Type)
+}
+
+func TestServer_MultiExec(t *testing.T) {
+	_, connect := startServer(t)
+	client := connect()
+
+	assert.Equal(t, "OK", client.do("SET", "key1", "value1").Str)
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "key2", "value2").Str)
+	assert.Equal(t, "QUEUED", client.do("DEL", "key1").Str)
+	assert.Equal(t, "QUEUED", client.do("GET", "key2").Str)
+
+	reply := client.do("EXEC")
+	assert.Equal(t, resp.Array, reply.Type)
+	assert.Len(t, reply.Elems, 3)
+	assert.Equal(t, "OK", reply.Elems[0].Str)
+	assert.Equal(t, int64(1), reply.Elems[1].Int)
+	assert.Equal(t, "value2", reply.Elems[2].Str)
+
+	assert.True(t, client.do("GET", "key1").IsNull)
+	assert.Equal(t, "value2", client.do("GET", "key2").Str)
+
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "key3", "value3").Str)
+	assert.Equal(t, "OK", client.do("DISCARD").Str)
+	assert.True(t, client.do("GET", "key3").IsNull)
+
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, resp.Error, client.do("SET", "key3").Type)
+	assert.Equal(t, resp.Error, client.do("EXEC").Type)
+}
+
+func TestServer_WatchConflict(t *testing.T) {
+	_, connect := startServer(t)
+	client, other := connect(), connect()
+
+	assert.Equal(t, "OK", client.do("SET", "counter", "1").Str)
+	assert.Equal(t, "OK", client.do("WATCH", "counter").Str)
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "counter", "2").Str)
+
+	assert.Equal(t, "OK", other.do("SET", "counter", "10").Str)
+
+	assert.True(t, client.do("EXEC").IsNull)
+	assert.Equal(t, "10", client.do("GET", "counter").Str)
+
+	// without interference the watched transaction goes through
+	assert.Equal(t, "OK", client.do("WATCH", "counter").Str)
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "counter", "11").Str)
+	assert.Len(t, client.do("EXEC").Elems, 1)
+	assert.Equal(t, "11", client.do("GET", "counter").Str)
+}
+
+func TestServer_WatchOnlyChecksWatchedKeys(t *testing.T) {
+	_, connect := startServer(t)
+	client, other := connect(), connect()
+
+	assert.Equal(t, "OK", client.do("SET", "counter", "1").Str)
+	assert.Equal(t, "OK", client.do("SET", "other", "1").Str)
+	assert.Equal(t, "OK", client.do("WATCH", "counter").Str)
+
+	// changes to unwatched keys after WATCH are visible to EXEC and do not abort it
+	assert.Equal(t, "OK", other.do("SET", "other", "2").Str)
+
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, "QUEUED", client.do("GET", "other").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "other", "3").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "counter", "2").Str)
+
+	reply := client.do("EXEC")
+	assert.Equal(t, resp.Array, reply.Type)
+	assert.Len(t, reply.Elems, 3)
+	assert.Equal(t, "2", reply.Elems[0].Str)
+	assert.Equal(t, "3", client.do("GET", "other").Str)
+	assert.Equal(t, "2", client.do("GET", "counter").Str)
+
+	// a watched key deleted since WATCH fails EXEC as well
+	assert.Equal(t, "OK", client.do("WATCH", "counter").Str)
+	assert.Equal(t, int64(1), other.do("DEL", "counter").Int)
+	assert.Equal(t, "OK", client.do("MULTI").Str)
+	assert.Equal(t, "QUEUED", client.do("SET", "counter", "3").Str)
+	assert.True(t, client.do("EXEC").IsNull)
+	assert.True(t, client.do("GET", "counter").IsNull)
+}
+
+// conflictingStore hands out transactions whose commits always conflict.
+type conflictingStore struct {
+	storage.MemStorage
+}
+
+func (s conflictingStore) Tx(opts ...storage.TxOption) storage.MemTx {
+	return conflictingTx{MemTx: s.MemStorage.Tx(opts...)}
+}
+
+type conflictingTx struct {
+	storage.MemTx
+}
+
+func (tx conflictingTx) Commit(ctx context.Context) error {
+	return appCommon.NewTxIDCanNotBeCommited(tx.ID())
+}
+
+func TestSession_ExecGivesUpOnRepeatedConflicts(t *testing.T) {
+	ctx := context.Background()
+	session := newSession(conflictingStore{MemStorage: storage.NewMemStore()})
+	writer := resp.NewWriter(io.Discard)
+
+	session.handle(ctx, writer, []string{"MULTI"})
+	session.handle(ctx, writer, []string{"SET", "key1", "value1"})
+	reply, _ := session.handle(ctx, writer, []string{"EXEC"})
+
+	// a null reply would tell the client a watched key changed
+	assert.Equal(t, resp.Error, reply.Type)
+	assert.False(t, reply.IsNull)
+	assert.Contains(t, reply.Str, "EXECABORT")
+}
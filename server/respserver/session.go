@@ -0,0 +1,217 @@
+package respserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/server/resp"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+// maxExecAttempts bounds how often an EXEC without WATCH is retried on a
+// commit conflict. Redis never fails such an EXEC, so we try hard to hide the
+// optimistic concurrency control from the client.
+const maxExecAttempts = 5
+
+// session is the per-connection state. Like Redis, WATCH only records the
+// versions of the watched keys: EXEC runs the queued commands on current data
+// and gives up if one of those keys changed since.
+type session struct {
+	store       storage.MemStorage
+	watched     map[string]int // key -> version at WATCH time, 0 if none
+	inMulti     bool
+	queued      [][]string
+	queueFailed bool
+}
+
+func newSession(store storage.MemStorage) *session {
+	return &session{store: store}
+}
+
+// handle runs one client command and returns its reply. The second result
+// tells the caller to close the connection after writing the reply.
+func (s *session) handle(ctx context.Context, writer *resp.Writer, args []string) (resp.Value, bool) {
+	name := strings.ToUpper(args[0])
+
+	switch name {
+	case "QUIT":
+		return resp.OK(), true
+	case "HELLO":
+		return hello(writer, args), false
+	case "MULTI":
+		return s.multi(), false
+	case "EXEC":
+		return s.exec(ctx), false
+	case "DISCARD":
+		return s.discard(ctx), false
+	case "WATCH":
+		return s.watch(ctx, args), false
+	case "UNWATCH":
+		if !s.inMulti {
+			s.reset()
+		}
+		return resp.OK(), false
+	}
+
+	if s.inMulti {
+		cmd, ok := commands[name]
+		if !ok {
+			s.queueFailed = true
+			return unknownCommand(args[0]), false
+		}
+		if !cmd.checkArity(len(args)) {
+			s.queueFailed = true
+			return wrongArity(args[0]), false
+		}
+		s.queued = append(s.queued, args)
+		return resp.NewSimpleString("QUEUED"), false
+	}
+
+	return execute(ctx, s.store, args), false
+}
+
+func (s *session) multi() resp.Value {
+	if s.inMulti {
+		return resp.NewError("ERR MULTI calls can not be nested")
+	}
+	s.inMulti = true
+	return resp.OK()
+}
+
+func (s *session) watch(ctx context.Context, args []string) resp.Value {
+	if s.inMulti {
+		return resp.NewError("ERR WATCH inside MULTI is not allowed")
+	}
+	if len(args) < 2 {
+		return wrongArity(args[0])
+	}
+	if s.watched == nil {
+		s.watched = make(map[string]int)
+	}
+	for _, key := range args[1:] {
+		if _, ok := s.watched[key]; ok {
+			continue
+		}
+		version, err := s.currentVersion(ctx, key)
+		if err != nil {
+			return errorReply(err)
+		}
+		s.watched[key] = version
+	}
+	return resp.OK()
+}
+
+// currentVersion is the txID of the latest committed version of key, deletes
+// included, or 0 if the key has none.
+func (s *session) currentVersion(ctx context.Context, key string) (int, error) {
+	history, err := s.store.History(ctx, key)
+	if errors.Is(err, appCommon.KeyDoesNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(history) == 0 {
+		return 0, nil
+	}
+	return history[len(history)-1].TxID, nil
+}
+
+// watchedKeysUnchanged reports whether every watched key is still at its
+// version from WATCH time.
+func (s *session) watchedKeysUnchanged(ctx context.Context) (bool, error) {
+	for key, watched := range s.watched {
+		version, err := s.currentVersion(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		if version != watched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *session) discard(ctx context.Context) resp.Value {
+	if !s.inMulti {
+		return resp.NewError("ERR DISCARD without MULTI")
+	}
+	s.reset()
+	return resp.OK()
+}
+
+func (s *session) exec(ctx context.Context) resp.Value {
+	if !s.inMulti {
+		return resp.NewError("ERR EXEC without MULTI")
+	}
+	if s.queueFailed {
+		s.reset()
+		return resp.NewError("EXECABORT Transaction discarded because of previous errors.")
+	}
+
+	watching := len(s.watched) > 0
+	queued := s.queued
+	defer s.reset()
+	for attempt := 1; ; attempt++ {
+		tx := s.store.Tx()
+		reply, retry := s.run(ctx, tx, queued)
+		if !retry {
+			return reply
+		}
+		if watching {
+			// a failed WATCH is reported as a null reply, like Redis does
+			return resp.NullArray()
+		}
+		if attempt >= maxExecAttempts {
+			return resp.NewError("EXECABORT Transaction discarded because of repeated write conflicts.")
+		}
+	}
+}
+
+// run executes the queued commands in tx and commits it. The second result
+// reports a conflict, either on commit or because a watched key changed.
+func (s *session) run(ctx context.Context, tx storage.MemTx, queued [][]string) (resp.Value, bool) {
+	if len(s.watched) > 0 {
+		keys := make([]string, 0, len(s.watched))
+		for key := range s.watched {
+			keys = append(keys, key)
+		}
+		// watch before comparing, so changes made after the comparison
+		// still fail the commit
+		if err := tx.Watch(ctx, keys...); err != nil {
+			_ = tx.Abort(ctx)
+			return errorReply(err), false
+		}
+		unchanged, err := s.watchedKeysUnchanged(ctx)
+		if err != nil {
+			_ = tx.Abort(ctx)
+			return errorReply(err), false
+		}
+		if !unchanged {
+			_ = tx.Abort(ctx)
+			return resp.Value{}, true
+		}
+	}
+
+	replies := make([]resp.Value, len(queued))
+	for i, args := range queued {
+		replies[i] = execute(ctx, tx, args)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		// a failed commit leaves the transaction open
+		_ = tx.Abort(ctx)
+		return errorReply(err), isConflict(err)
+	}
+	return resp.NewArray(replies...), false
+}
+
+// reset leaves MULTI and forgets the watched keys.
+func (s *session) reset() {
+	s.watched = nil
+	s.inMulti = false
+	s.queued = nil
+	s.queueFailed = false
+}
@@ -0,0 +1,278 @@
+package btree
+
+import "sort"
+
+const DefaultDegree = 32
+
+type item[V any] struct {
+	key   string
+	value V
+}
+
+type node[V any] struct {
+	items    []item[V]
+	children []*node[V]
+}
+
+func (n *node[V]) leaf() bool {
+	return len(n.children) == 0
+}
+
+// search returns the index of the first item whose key is >= key and whether
+// that item holds key exactly.
+func (n *node[V]) search(key string) (int, bool) {
+	i := sort.Search(len(n.items), func(i int) bool { return n.items[i].key >= key })
+	return i, i < len(n.items) && n.items[i].key == key
+}
+
+// BTree is an ordered map from string keys to values. It is not safe for
+// concurrent use, callers guard it with their own lock (the store does so with
+// its rwMutex).
+type BTree[V any] struct {
+	root   *node[V]
+	degree int
+	length int
+}
+
+// New creates a tree whose nodes hold between degree-1 and 2*degree-1 items.
+func New[V any](degree int) *BTree[V] {
+	if degree < 2 {
+		degree = DefaultDegree
+	}
+	return &BTree[V]{root: &node[V]{}, degree: degree}
+}
+
+func (t *BTree[V]) Len() int {
+	return t.length
+}
+
+func (t *BTree[V]) maxItems() int {
+	return 2*t.degree - 1
+}
+
+func (t *BTree[V]) Get(key string) (V, bool) {
+	n := t.root
+	for {
+		i, found := n.search(key)
+		if found {
+			return n.items[i].value, true
+		}
+		if n.leaf() {
+			var zero V
+			return zero, false
+		}
+		n = n.children[i]
+	}
+}
+
+func (t *BTree[V]) Has(key string) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// Set inserts key or replaces its value.
+func (t *BTree[V]) Set(key string, value V) {
+	if len(t.root.items) == t.maxItems() {
+		root := &node[V]{children: []*node[V]{t.root}}
+		t.splitChild(root, 0)
+		t.root = root
+	}
+	if t.insertNonFull(t.root, item[V]{key: key, value: value}) {
+		t.length++
+	}
+}
+
+// splitChild splits the full child i of parent around its median item.
+func (t *BTree[V]) splitChild(parent *node[V], i int) {
+	full := parent.children[i]
+	middle := t.degree - 1
+
+	right := &node[V]{items: append([]item[V]{}, full.items[middle+1:]...)}
+	if !full.leaf() {
+		right.children = append([]*node[V]{}, full.children[middle+1:]...)
+		full.children = full.children[:middle+1]
+	}
+	median := full.items[middle]
+	full.items = full.items[:middle]
+
+	parent.items = append(parent.items, item[V]{})
+	copy(parent.items[i+1:], parent.items[i:])
+	parent.items[i] = median
+
+	parent.children = append(parent.children, nil)
+	copy(parent.children[i+2:], parent.children[i+1:])
+	parent.children[i+1] = right
+}
+
+func (t *BTree[V]) insertNonFull(n *node[V], it item[V]) bool {
+	for {
+		i, found := n.search(it.key)
+		if found {
+			n.items[i].value = it.value
+			return false
+		}
+		if n.leaf() {
+			n.items = append(n.items, item[V]{})
+			copy(n.items[i+1:], n.items[i:])
+			n.items[i] = it
+			return true
+		}
+		if len(n.children[i].items) == t.maxItems() {
+			t.splitChild(n, i)
+			if n.items[i].key == it.key {
+				n.items[i].value = it.value
+				return false
+			}
+			if it.key > n.items[i].key {
+				i++
+			}
+		}
+		n = n.children[i]
+	}
+}
+
+// Delete removes key and reports whether it was present.
+func (t *BTree[V]) Delete(key string) bool {
+	deleted := t.delete(t.root, key)
+	if len(t.root.items) == 0 && !t.root.leaf() {
+		t.root = t.root.children[0]
+	}
+	if deleted {
+		t.length--
+	}
+	return deleted
+}
+
+func (t *BTree[V]) delete(n *node[V], key string) bool {
+	i, found := n.search(key)
+
+	if found {
+		if n.leaf() {
+			n.items = append(n.items[:i], n.items[i+1:]...)
+			return true
+		}
+		switch {
+		case len(n.children[i].items) >= t.degree:
+			predecessor := n.children[i].max()
+			n.items[i] = predecessor
+			return t.delete(n.children[i], predecessor.key)
+		case len(n.children[i+1].items) >= t.degree:
+			successor := n.children[i+1].min()
+			n.items[i] = successor
+			return t.delete(n.children[i+1], successor.key)
+		default:
+			t.merge(n, i)
+			return t.delete(n.children[i], key)
+		}
+	}
+
+	if n.leaf() {
+		return false
+	}
+
+	// make sure the child we descend into can lose an item
+	if len(n.children[i].items) < t.degree {
+		switch {
+		case i > 0 && len(n.children[i-1].items) >= t.degree:
+			t.rotateRight(n, i)
+		case i < len(n.children)-1 && len(n.children[i+1].items) >= t.degree:
+			t.rotateLeft(n, i)
+		case i < len(n.children)-1:
+			t.merge(n, i)
+		default:
+			t.merge(n, i-1)
+			i--
+		}
+	}
+	return t.delete(n.children[i], key)
+}
+
+// merge folds item i and child i+1 of n into child i.
+func (t *BTree[V]) merge(n *node[V], i int) {
+	left, right := n.children[i], n.children[i+1]
+	left.items = append(append(left.items, n.items[i]), right.items...)
+	left.children = append(left.children, right.children...)
+
+	n.items = append(n.items[:i], n.items[i+1:]...)
+	n.children = append(n.children[:i+1], n.children[i+2:]...)
+}
+
+// rotateRight moves the last item of child i-1 up and item i-1 down into child i.
+func (t *BTree[V]) rotateRight(n *node[V], i int) {
+	child, sibling := n.children[i], n.children[i-1]
+
+	child.items = append([]item[V]{n.items[i-1]}, child.items...)
+	n.items[i-1] = sibling.items[len(sibling.items)-1]
+	sibling.items = sibling.items[:len(sibling.items)-1]
+
+	if !sibling.leaf() {
+		child.children = append([]*node[V]{sibling.children[len(sibling.children)-1]}, child.children...)
+		sibling.children = sibling.children[:len(sibling.children)-1]
+	}
+}
+
+// rotateLeft moves the first item of child i+1 up and item i down into child i.
+func (t *BTree[V]) rotateLeft(n *node[V], i int) {
+	child, sibling := n.children[i], n.children[i+1]
+
+	child.items = append(child.items, n.items[i])
+	n.items[i] = sibling.items[0]
+	sibling.items = append([]item[V]{}, sibling.items[1:]...)
+
+	if !sibling.leaf() {
+		child.children = append(child.children, sibling.children[0])
+		sibling.children = append([]*node[V]{}, sibling.children[1:]...)
+	}
+}
+
+func (n *node[V]) min() item[V] {
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return n.items[0]
+}
+
+func (n *node[V]) max() item[V] {
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	return n.items[len(n.items)-1]
+}
+
+// Ascend calls fn for every key in [start, end) in order until fn returns
+// false. An empty end means no upper bound.
+func (t *BTree[V]) Ascend(start, end string, fn func(key string, value V) bool) {
+	t.ascend(t.root, start, end, fn)
+}
+
+func (t *BTree[V]) ascend(n *node[V], start, end string, fn func(key string, value V) bool) bool {
+	i, _ := n.search(start)
+	for ; i < len(n.items); i++ {
+		if !n.leaf() && !t.ascend(n.children[i], start, end, fn) {
+			return false
+		}
+		if end != "" && n.items[i].key >= end {
+			return false
+		}
+		if !fn(n.items[i].key, n.items[i].value) {
+			return false
+		}
+	}
+	if !n.leaf() {
+		return t.ascend(n.children[i], start, end, fn)
+	}
+	return true
+}
+
+// PrefixEnd returns the smallest key greater than every key starting with
+// prefix, or "" if there is none (the prefix is all 0xff bytes).
+func PrefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package btree_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/storage_engine/btree"
+)
+
+func collect(tree *btree.BTree[int], start, end string) []string {
+	var keys []string
+	tree.Ascend(start, end, func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func TestBTree_RandomOperations(t *testing.T) {
+	for _, degree := range []int{2, 3, 32} {
+		t.Run(fmt.Sprintf("degree %d", degree), func(t *testing.T) {
+			tree := btree.New[int](degree)
+			expected := make(map[string]int)
+			random := rand.New(rand.NewSource(int64(degree)))
+
+			for i := 0; i < 5000; i++ {
+				key := fmt.Sprintf("key%04d", random.Intn(1000))
+				if random.Intn(3) == 0 {
+					_, existed := expected[key]
+					assert.Equal(t, existed, tree.Delete(key))
+					delete(expected, key)
+				} else {
+					tree.Set(key, i)
+					expected[key] = i
+				}
+			}
+
+			keys := make([]string, 0, len(expected))
+			for key, value := range expected {
+				keys = append(keys, key)
+				got, ok := tree.Get(key)
+				assert.True(t, ok)
+				assert.Equal(t, value, got)
+			}
+			sort.Strings(keys)
+
+			assert.Equal(t, len(expected), tree.Len())
+			assert.Equal(t, keys, collect(tree, "", ""))
+		})
+	}
+}
+
+func TestBTree_Ascend(t *testing.T) {
+	tree := btree.New[int](2)
+	for i, key := range []string{"b", "a", "d", "c", "ab", "e", "ba"} {
+		tree.Set(key, i)
+	}
+
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		expect []string
+	}{
+		{"everything", "", "", []string{"a", "ab", "b", "ba", "c", "d", "e"}},
+		{"bounded", "ab", "c", []string{"ab", "b", "ba"}},
+		{"start between keys", "aa", "", []string{"ab", "b", "ba", "c", "d", "e"}},
+		{"empty range", "f", "", nil},
+		{"prefix", "b", btree.PrefixEnd("b"), []string{"b", "ba"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, collect(tree, tt.start, tt.end))
+		})
+	}
+
+	var firstTwo []string
+	tree.Ascend("", "", func(key string, _ int) bool {
+		firstTwo = append(firstTwo, key)
+		return len(firstTwo) < 2
+	})
+	assert.Equal(t, []string{"a", "ab"}, firstTwo)
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, "b", btree.PrefixEnd("a"))
+	assert.Equal(t, "user;", btree.PrefixEnd("user:"))
+	assert.Equal(t, "b", btree.PrefixEnd("a\xff"))
+	assert.Equal(t, "", btree.PrefixEnd("\xff\xff"))
+	assert.Equal(t, "", btree.PrefixEnd(""))
+}
@@ -0,0 +1,206 @@
+package index
+
+import (
+	"in-memory-storage-engine/storage_engine/btree"
+)
+
+// Func extracts the index keys of a stored value. It returns nothing for
+// values that do not belong in the index.
+type Func func(value interface{}) []Tuple
+
+// Latest asks Ascend for the entries of the latest committed versions instead
+// of those visible at a snapshot txID.
+const Latest = -1
+
+// Index maps tuples to the keys whose value produced them. Like the store it
+// is multi-versioned: each entry remembers the txID ranges during which it was
+// valid, so it can be read at any snapshot the store still keeps.
+//
+// It is not safe for concurrent use, the store guards it with its rwMutex.
+type Index interface {
+	Name() string
+	// Len counts the entries kept, including those only older snapshots see.
+	Len() int
+	// Set records that key holds value from txID on.
+	Set(key string, value interface{}, txID int)
+	// Delete records that key holds nothing from txID on.
+	Delete(key string, txID int)
+	// Remove forgets every entry of key, e.g. once the key is evicted.
+	Remove(key string)
+	// Prune forgets entries that stopped being valid at or before watermark.
+	Prune(watermark int)
+	// Ascend calls fn in index order for the entries in [from, to) that are
+	// valid at txID (or Latest). An empty to means no upper bound.
+	Ascend(from, to string, txID int, fn func(position, key string) bool)
+	// Extract returns the tuples value is indexed under.
+	Extract(value interface{}) []Tuple
+	// Positions returns where key would appear in the index if it held value.
+	Positions(key string, value interface{}) []string
+}
+
+// Position is where key sits in an index for tuple. The key is the last
+// element, so equal tuples stay distinct per key.
+func Position(tuple Tuple, key string) string {
+	return append(append(Tuple{}, tuple...), key).encode()
+}
+
+// LookupBounds returns the Ascend bounds of the entries whose tuple equals
+// tuple, or starts with it for a prefix of a composite index.
+func LookupBounds(tuple Tuple) (string, string) {
+	from := tuple.encode()
+	return from, btree.PrefixEnd(from)
+}
+
+// RangeBounds returns the Ascend bounds of the tuples in [start, end). A nil
+// end means no upper bound.
+func RangeBounds(start, end Tuple) (string, string) {
+	if end == nil {
+		return start.encode(), ""
+	}
+	return start.encode(), end.encode()
+}
+
+// interval is [from, to) in txIDs, to == 0 while it is still open.
+type interval struct {
+	from int
+	to   int
+}
+
+type entry struct {
+	key       string
+	intervals []interval
+}
+
+func (e *entry) open() bool {
+	return len(e.intervals) > 0 && e.intervals[len(e.intervals)-1].to == 0
+}
+
+func (e *entry) validAt(txID int) bool {
+	if txID == Latest {
+		return e.open()
+	}
+	for _, iv := range e.intervals {
+		if iv.from <= txID && (iv.to == 0 || txID < iv.to) {
+			return true
+		}
+	}
+	return false
+}
+
+type btreeIndex struct {
+	name    string
+	extract Func
+	entries *btree.BTree[*entry]
+	byKey   map[string]map[string]*entry // key -> position -> entry
+}
+
+func New(name string, extract Func) Index {
+	return &btreeIndex{
+		name:    name,
+		extract: extract,
+		entries: btree.New[*entry](btree.DefaultDegree),
+		byKey:   make(map[string]map[string]*entry),
+	}
+}
+
+func (index *btreeIndex) Name() string {
+	return index.name
+}
+
+func (index *btreeIndex) Len() int {
+	return index.entries.Len()
+}
+
+func (index *btreeIndex) Extract(value interface{}) []Tuple {
+	if value == nil {
+		return nil
+	}
+	return index.extract(value)
+}
+
+func (index *btreeIndex) Positions(key string, value interface{}) []string {
+	tuples := index.Extract(value)
+	positions := make([]string, 0, len(tuples))
+	seen := make(map[string]struct{}, len(tuples))
+	for _, tuple := range tuples {
+		position := Position(tuple, key)
+		if _, dup := seen[position]; !dup {
+			seen[position] = struct{}{}
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+func (index *btreeIndex) Set(key string, value interface{}, txID int) {
+	wanted := make(map[string]struct{})
+	for _, position := range index.Positions(key, value) {
+		wanted[position] = struct{}{}
+	}
+
+	for position, e := range index.byKey[key] {
+		if _, keep := wanted[position]; keep && e.open() {
+			delete(wanted, position)
+		} else if e.open() {
+			e.intervals[len(e.intervals)-1].to = txID
+		}
+	}
+
+	for position := range wanted {
+		e, exist := index.entries.Get(position)
+		if !exist {
+			e = &entry{key: key}
+			index.entries.Set(position, e)
+			if index.byKey[key] == nil {
+				index.byKey[key] = make(map[string]*entry)
+			}
+			index.byKey[key][position] = e
+		}
+		e.intervals = append(e.intervals, interval{from: txID})
+	}
+}
+
+func (index *btreeIndex) Delete(key string, txID int) {
+	for _, e := range index.byKey[key] {
+		if e.open() {
+			e.intervals[len(e.intervals)-1].to = txID
+		}
+	}
+}
+
+func (index *btreeIndex) Remove(key string) {
+	for position := range index.byKey[key] {
+		index.entries.Delete(position)
+	}
+	delete(index.byKey, key)
+}
+
+func (index *btreeIndex) Prune(watermark int) {
+	for key, positions := range index.byKey {
+		for position, e := range positions {
+			kept := e.intervals[:0]
+			for _, iv := range e.intervals {
+				if iv.to == 0 || iv.to > watermark {
+					kept = append(kept, iv)
+				}
+			}
+			e.intervals = kept
+			if len(kept) == 0 {
+				index.entries.Delete(position)
+				delete(positions, position)
+			}
+		}
+		if len(positions) == 0 {
+			delete(index.byKey, key)
+		}
+	}
+}
+
+func (index *btreeIndex) Ascend(from, to string, txID int, fn func(position, key string) bool) {
+	index.entries.Ascend(from, to, func(position string, e *entry) bool {
+		if !e.validAt(txID) {
+			return true
+		}
+		return fn(position, e.key)
+	})
+}
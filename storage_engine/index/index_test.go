@@ -0,0 +1,118 @@
+package index
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTuple_Order(t *testing.T) {
+	ordered := []Tuple{
+		{false},
+		{true},
+		{math.MinInt64},
+		{-5},
+		{0},
+		{uint8(3)},
+		{7},
+		{uint64(9)},
+		{uint(10)},
+		{math.MaxInt64},
+		{uint64(math.MaxInt64 + 1)},
+		{uint64(math.MaxUint64)},
+		{-1.5},
+		{2.25},
+		{""},
+		{"a"},
+		{"a", 1},
+		{"a", "b"},
+		{"a\x00"},
+		{"ab"},
+		{"b"},
+	}
+
+	encoded := make([]string, len(ordered))
+	for i, tuple := range ordered {
+		encoded[i] = tuple.encode()
+	}
+	assert.True(t, sort.StringsAreSorted(encoded))
+	assert.Equal(t, Tuple{7}.encode(), Tuple{uint64(7)}.encode())
+}
+
+func byEmail(value interface{}) []Tuple {
+	user, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return []Tuple{{user["email"]}}
+}
+
+func collect(index Index, from, to string, txID int) []string {
+	keys := make([]string, 0)
+	index.Ascend(from, to, txID, func(_, key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func TestIndex_Versions(t *testing.T) {
+	index := New("email", byEmail)
+	index.Set("user:1", map[string]interface{}{"email": "a@x"}, 1)
+	index.Set("user:2", map[string]interface{}{"email": "b@x"}, 2)
+	index.Set("user:1", map[string]interface{}{"email": "c@x"}, 3)
+	index.Delete("user:2", 4)
+
+	from, to := LookupBounds(Tuple{"a@x"})
+	assert.Equal(t, []string{"user:1"}, collect(index, from, to, 2))
+	assert.Empty(t, collect(index, from, to, Latest))
+
+	assert.Equal(t, []string{"user:1", "user:2"}, collect(index, "", "", 2))
+	assert.Equal(t, []string{"user:2", "user:1"}, collect(index, "", "", 3))
+	assert.Equal(t, []string{"user:1"}, collect(index, "", "", Latest))
+
+	from, to = RangeBounds(Tuple{"b"}, Tuple{"c"})
+	assert.Equal(t, []string{"user:2"}, collect(index, from, to, 3))
+
+	index.Prune(4)
+	assert.Empty(t, collect(index, "", "", 2))
+	assert.Equal(t, []string{"user:1"}, collect(index, "", "", Latest))
+
+	index.Remove("user:1")
+	assert.Empty(t, collect(index, "", "", Latest))
+}
+
+func TestIndex_Composite(t *testing.T) {
+	byCityAge := func(value interface{}) []Tuple {
+		user := value.(map[string]interface{})
+		return []Tuple{{user["city"], user["age"]}}
+	}
+	index := New("city_age", byCityAge)
+	index.Set("u1", map[string]interface{}{"city": "Hanoi", "age": 30}, 1)
+	index.Set("u2", map[string]interface{}{"city": "Hanoi", "age": 20}, 1)
+	index.Set("u3", map[string]interface{}{"city": "Hue", "age": 25}, 1)
+
+	from, to := LookupBounds(Tuple{"Hanoi"})
+	assert.Equal(t, []string{"u2", "u1"}, collect(index, from, to, Latest))
+
+	from, to = RangeBounds(Tuple{"Hanoi", 25}, Tuple{"Hue"})
+	assert.Equal(t, []string{"u1"}, collect(index, from, to, Latest))
+}
+
+func TestIndex_Uint64Range(t *testing.T) {
+	byValue := func(value interface{}) []Tuple {
+		return []Tuple{{value}}
+	}
+	index := New("size", byValue)
+	for key, size := range map[string]uint64{"a": 9, "b": 10, "c": 100, "d": math.MaxUint64} {
+		index.Set(key, size, 1)
+	}
+
+	from, to := RangeBounds(Tuple{uint64(9)}, Tuple{uint64(101)})
+	assert.Equal(t, []string{"a", "b", "c"}, collect(index, from, to, Latest))
+
+	from, to = RangeBounds(Tuple{uint64(10)}, nil)
+	assert.Equal(t, []string{"b", "c", "d"}, collect(index, from, to, Latest))
+}
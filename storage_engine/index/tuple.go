@@ -0,0 +1,132 @@
+package index
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Tuple is an index key. A single field index uses one element, a composite
+// index one element per field, compared left to right.
+//
+// Elements of the same kind order naturally: bools, integers (signed and
+// unsigned alike), floats and strings are supported, anything else orders by
+// its fmt.Sprint text. Kinds order in that sequence and never compare equal to
+// each other, so the integer 1 and the float 1.0 are different keys.
+type Tuple []interface{}
+
+const (
+	tagNil byte = iota + 1
+	tagBool
+	tagInt
+	tagFloat
+	tagString
+	tagOther
+)
+
+// encode returns a string whose byte order is the order of t. Every element
+// is self-delimiting, so the encoding of a tuple prefixes the encoding of any
+// longer tuple starting with it.
+func (t Tuple) encode() string {
+	var builder strings.Builder
+	for _, element := range t {
+		encodeElement(&builder, element)
+	}
+	return builder.String()
+}
+
+func encodeElement(builder *strings.Builder, element interface{}) {
+	switch v := element.(type) {
+	case nil:
+		builder.WriteByte(tagNil)
+	case bool:
+		builder.WriteByte(tagBool)
+		if v {
+			builder.WriteByte(1)
+		} else {
+			builder.WriteByte(0)
+		}
+	case int:
+		encodeInt(builder, int64(v))
+	case int8:
+		encodeInt(builder, int64(v))
+	case int16:
+		encodeInt(builder, int64(v))
+	case int32:
+		encodeInt(builder, int64(v))
+	case int64:
+		encodeInt(builder, v)
+	case uint8:
+		encodeInt(builder, int64(v))
+	case uint16:
+		encodeInt(builder, int64(v))
+	case uint32:
+		encodeInt(builder, int64(v))
+	case uint:
+		encodeUint(builder, uint64(v))
+	case uint64:
+		encodeUint(builder, v)
+	case uintptr:
+		encodeUint(builder, uint64(v))
+	case float32:
+		encodeFloat(builder, float64(v))
+	case float64:
+		encodeFloat(builder, v)
+	case string:
+		builder.WriteByte(tagString)
+		encodeString(builder, v)
+	default:
+		builder.WriteByte(tagOther)
+		encodeString(builder, fmt.Sprint(v))
+	}
+}
+
+// Integers are a sign byte, 0 for negative numbers, followed by the 64 bits of
+// the two's complement, so every int64 and uint64 value has its place and an
+// int and a uint of the same value are the same key.
+func encodeInt(builder *strings.Builder, v int64) {
+	var sign byte = 1
+	if v < 0 {
+		sign = 0
+	}
+	encodeInteger(builder, sign, uint64(v))
+}
+
+func encodeUint(builder *strings.Builder, v uint64) {
+	encodeInteger(builder, 1, v)
+}
+
+func encodeInteger(builder *strings.Builder, sign byte, bits uint64) {
+	var buffer [8]byte
+	binary.BigEndian.PutUint64(buffer[:], bits)
+	builder.WriteByte(tagInt)
+	builder.WriteByte(sign)
+	builder.Write(buffer[:])
+}
+
+func encodeFloat(builder *strings.Builder, v float64) {
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	var buffer [8]byte
+	binary.BigEndian.PutUint64(buffer[:], bits)
+	builder.WriteByte(tagFloat)
+	builder.Write(buffer[:])
+}
+
+// encodeString escapes 0x00 as 0x00 0xff and ends with 0x00 0x01, which keeps
+// "a" before "a\x00" and "ab".
+func encodeString(builder *strings.Builder, v string) {
+	for i := 0; i < len(v); i++ {
+		builder.WriteByte(v[i])
+		if v[i] == 0 {
+			builder.WriteByte(0xff)
+		}
+	}
+	builder.WriteByte(0)
+	builder.WriteByte(1)
+}
@@ -3,6 +3,7 @@ package operation
 import (
 	"in-memory-storage-engine/appCommon"
 	"sync"
+	"time"
 )
 
 const (
@@ -15,20 +16,43 @@ type KeyStore interface {
 	Get(key string) interface{}
 	Set(key string, value interface{})
 	Delete(key string) error
+	// Put records op for key as is, e.g. a delete of a key that only exists in
+	// committed storage.
+	Put(key string, op Operation)
 	CheckIfKeyExists(key string) bool
+	GetOperation(key string) (Operation, bool)
 	GetAllOperation() *map[string]Operation
+	// CopyOperations returns a copy of the pending operations that is safe to
+	// use while the transaction keeps writing.
+	CopyOperations() map[string]Operation
+	// Savepoint marks the current operations under name. RollbackTo restores
+	// them, keeping the savepoint but dropping the later ones, and Release
+	// drops the savepoint (and the later ones) keeping the operations. Names
+	// may repeat, the latest savepoint of a name is used.
+	Savepoint(name string)
+	RollbackTo(name string) error
+	Release(name string) error
 }
 
 type Operation struct {
 	OperationType int
 	Value         interface{}
+	ExpireAt      time.Time // zero means the value never expires
 }
 
 type operationsKeyStore struct {
 	operationStore map[string]Operation
+	savepoints     *[]savepoint
 	writer         *sync.RWMutex
 }
 
+// savepoint keeps, for each key written since it was taken (and before the
+// next savepoint), the operation the key had then, nil if it had none.
+type savepoint struct {
+	name string
+	undo map[string]*Operation
+}
+
 func newSetOperation(value interface{}) Operation {
 	return Operation{
 		OperationType: SET,
@@ -45,6 +69,7 @@ func newDeleteOperation() Operation {
 func NewOperationsKeyStore() KeyStore {
 	return operationsKeyStore{
 		operationStore: make(map[string]Operation),
+		savepoints:     new([]savepoint),
 		writer:         new(sync.RWMutex),
 	}
 }
@@ -75,6 +100,7 @@ func (s operationsKeyStore) Delete(key string) error {
 		return appCommon.KeyDoesNotExist
 	}
 
+	s.remember(key)
 	s.operationStore[key] = newDeleteOperation()
 	return nil
 }
@@ -82,13 +108,124 @@ func (s operationsKeyStore) Delete(key string) error {
 func (s operationsKeyStore) Set(key string, value interface{}) {
 	s.writer.Lock()
 	defer s.writer.Unlock()
+	s.remember(key)
 	s.operationStore[key] = newSetOperation(value)
 }
 
+func (s operationsKeyStore) Put(key string, op Operation) {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+	s.remember(key)
+	s.operationStore[key] = op
+}
+
+// remember records the operation of key in the latest savepoint, unless key
+// was already written since. Callers must hold the writer lock.
+func (s operationsKeyStore) remember(key string) {
+	savepoints := *s.savepoints
+	if len(savepoints) == 0 {
+		return
+	}
+	undo := savepoints[len(savepoints)-1].undo
+	if _, ok := undo[key]; ok {
+		return
+	}
+	if op, exist := s.operationStore[key]; exist {
+		undo[key] = &op
+	} else {
+		undo[key] = nil
+	}
+}
+
+func (s operationsKeyStore) Savepoint(name string) {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+	*s.savepoints = append(*s.savepoints, savepoint{name: name, undo: make(map[string]*Operation)})
+}
+
+func (s operationsKeyStore) RollbackTo(name string) error {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+
+	i := s.findSavepoint(name)
+	if i < 0 {
+		return appCommon.NewSavepointDoesNotExistError(name)
+	}
+	savepoints := *s.savepoints
+	// newest first, so each key ends up as the oldest savepoint saw it
+	for j := len(savepoints) - 1; j >= i; j-- {
+		for key, op := range savepoints[j].undo {
+			if op == nil {
+				delete(s.operationStore, key)
+			} else {
+				s.operationStore[key] = *op
+			}
+		}
+	}
+	savepoints[i].undo = make(map[string]*Operation)
+	*s.savepoints = savepoints[:i+1]
+	return nil
+}
+
+func (s operationsKeyStore) Release(name string) error {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+
+	i := s.findSavepoint(name)
+	if i < 0 {
+		return appCommon.NewSavepointDoesNotExistError(name)
+	}
+	savepoints := *s.savepoints
+	// the enclosing savepoint takes over what it has not seen yet, oldest first
+	if i > 0 {
+		undo := savepoints[i-1].undo
+		for _, released := range savepoints[i:] {
+			for key, op := range released.undo {
+				if _, ok := undo[key]; !ok {
+					undo[key] = op
+				}
+			}
+		}
+	}
+	*s.savepoints = savepoints[:i]
+	return nil
+}
+
+// findSavepoint returns the index of the latest savepoint named name, -1 if
+// there is none. Callers must hold the writer lock.
+func (s operationsKeyStore) findSavepoint(name string) int {
+	savepoints := *s.savepoints
+	for i := len(savepoints) - 1; i >= 0; i-- {
+		if savepoints[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func (s operationsKeyStore) GetAllOperation() *map[string]Operation {
 	return &s.operationStore
 }
 
+func (s operationsKeyStore) CopyOperations() map[string]Operation {
+	s.writer.RLock()
+	defer s.writer.RUnlock()
+
+	operations := make(map[string]Operation, len(s.operationStore))
+	for key, op := range s.operationStore {
+		operations[key] = op
+	}
+	return operations
+}
+
+func (s operationsKeyStore) GetOperation(key string) (Operation, bool) {
+	s.writer.RLock()
+	defer s.writer.RUnlock()
+
+	operation, exist := s.operationStore[key]
+	return operation, exist
+}
+
 func (s operationsKeyStore) Get(key string) interface{} {
 	s.writer.RLock()
 	defer s.writer.RUnlock()
@@ -91,3 +91,59 @@ func TestOperationsKeyStore(t *testing.T) {
 		})
 	}
 }
+
+func TestOperationsKeyStore_Savepoints(t *testing.T) {
+	t.Run("RollbackTo restores the operations at the savepoint", func(t *testing.T) {
+		store := operation.NewOperationsKeyStore()
+		store.Set("a", 1)
+		store.Put("b", operation.Operation{OperationType: operation.DELETE})
+		store.Savepoint("sp")
+
+		store.Set("a", 2)
+		store.Set("b", 3)
+		store.Set("c", 4)
+		assert.NoError(t, store.Delete("a"))
+
+		assert.NoError(t, store.RollbackTo("sp"))
+		assert.Equal(t, map[string]operation.Operation{
+			"a": {OperationType: operation.SET, Value: 1},
+			"b": {OperationType: operation.DELETE},
+		}, store.CopyOperations())
+
+		// the savepoint stays for another rollback
+		store.Set("a", 5)
+		assert.NoError(t, store.RollbackTo("sp"))
+		assert.Equal(t, 1, store.Get("a"))
+	})
+
+	t.Run("Nested savepoints", func(t *testing.T) {
+		store := operation.NewOperationsKeyStore()
+		store.Savepoint("outer")
+		store.Set("a", 1)
+		store.Savepoint("inner")
+		store.Set("a", 2)
+		store.Set("b", 2)
+
+		assert.NoError(t, store.RollbackTo("outer"))
+		assert.Empty(t, store.CopyOperations())
+		assert.Error(t, store.RollbackTo("inner"))
+	})
+
+	t.Run("Release hands the undo to the enclosing savepoint", func(t *testing.T) {
+		store := operation.NewOperationsKeyStore()
+		store.Set("a", 1)
+		store.Savepoint("outer")
+		store.Savepoint("inner")
+		store.Set("a", 2)
+		store.Set("b", 2)
+
+		assert.NoError(t, store.Release("inner"))
+		assert.Error(t, store.Release("inner"))
+		assert.Equal(t, 2, store.Get("a"))
+
+		assert.NoError(t, store.RollbackTo("outer"))
+		assert.Equal(t, map[string]operation.Operation{
+			"a": {OperationType: operation.SET, Value: 1},
+		}, store.CopyOperations())
+	})
+}
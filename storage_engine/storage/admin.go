@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/operation"
+	"sort"
+	"time"
+)
+
+// PendingWrite is one uncommitted operation of an open transaction.
+type PendingWrite struct {
+	Key     string
+	Deleted bool
+	Value   interface{}
+}
+
+type TransactionInfo struct {
+	TxID      int
+	StartedAt time.Time
+	Deadline  time.Time // zero if the transaction never expires
+	Writes    []PendingWrite
+}
+
+func (s *memStore) Transactions(ctx context.Context) []TransactionInfo {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	transactions := make([]TransactionInfo, 0, len(s.affectedKeysInTransaction))
+	for txID, keyStore := range s.affectedKeysInTransaction {
+		operations := keyStore.CopyOperations()
+		writes := make([]PendingWrite, 0, len(operations))
+		for key, op := range operations {
+			writes = append(writes, PendingWrite{
+				Key:     key,
+				Deleted: op.OperationType == operation.DELETE,
+				Value:   op.Value,
+			})
+		}
+		sort.Slice(writes, func(i, j int) bool { return writes[i].Key < writes[j].Key })
+
+		transactions = append(transactions, TransactionInfo{
+			TxID:      txID,
+			StartedAt: s.transactionStartedAt[txID],
+			Deadline:  s.transactionDeadline[txID],
+			Writes:    writes,
+		})
+	}
+	sort.Slice(transactions, func(i, j int) bool { return transactions[i].TxID < transactions[j].TxID })
+	return transactions
+}
+
+func (s *memStore) AbortTransaction(ctx context.Context, txID int) error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if !s.checkTxExist(txID) {
+		s.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(txID))
+		return appCommon.NewTxIDDoesNotExistError(txID)
+	}
+
+	s.logger.Infof("Transaction %d is aborted by an administrator", txID)
+	s.removeTransaction(txID)
+	return nil
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/wal"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultChangelogSize is how many commits the store keeps for subscribers
+// unless WithChangelogSize says otherwise.
+const DefaultChangelogSize = 4096
+
+// SubscribeFromNow subscribes to the commits that happen from now on only.
+const SubscribeFromNow = -1
+
+// CommitEvent is everything one commit wrote, in key order.
+type CommitEvent struct {
+	TxID        int
+	CommittedAt time.Time
+	Changes     []Change
+}
+
+type Change struct {
+	Key      string
+	Deleted  bool
+	Value    interface{}
+	ExpireAt time.Time
+}
+
+// Subscription reads commit events in txID order. The store never waits for
+// it: a subscriber falling behind by more than the changelog size gets a
+// *appCommon.ChangelogTruncatedError instead of stalling commits.
+type Subscription interface {
+	// Next blocks until the next commit event is available or ctx (or the
+	// context given to Subscribe) is done.
+	Next(ctx context.Context) (CommitEvent, error)
+	// Cursor is the txID of the last event returned, to resume from later.
+	Cursor() int
+}
+
+// changelog is a ring of the latest commit events. It has its own lock so
+// subscribers never touch the store lock.
+type changelog struct {
+	mutex     sync.Mutex
+	events    []CommitEvent
+	start     int
+	count     int
+	trimmed   int           // txID of the newest event dropped from the ring
+	published chan struct{} // closed and replaced on every append
+}
+
+func newChangelog(size int) *changelog {
+	return &changelog{
+		events:    make([]CommitEvent, max(size, 1)),
+		published: make(chan struct{}),
+	}
+}
+
+func (log *changelog) append(event CommitEvent) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if log.count == len(log.events) {
+		log.trimmed = log.events[log.start].TxID
+		log.events[log.start] = CommitEvent{}
+		log.start = (log.start + 1) % len(log.events)
+		log.count--
+	}
+	log.events[(log.start+log.count)%len(log.events)] = event
+	log.count++
+
+	close(log.published)
+	log.published = make(chan struct{})
+}
+
+// startAfter marks the events up to txID as no longer kept, for a store that
+// was restored from a snapshot or a truncated WAL and so never saw them.
+func (log *changelog) startAfter(txID int) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	log.trimmed = max(log.trimmed, txID)
+}
+
+// after returns the first event newer than txID, or the channel closed by the
+// next append if there is none yet.
+func (log *changelog) after(txID int) (CommitEvent, bool, <-chan struct{}, error) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if txID < log.trimmed {
+		oldest := log.trimmed + 1
+		if log.count > 0 {
+			oldest = log.events[log.start].TxID
+		}
+		return CommitEvent{}, false, nil, appCommon.NewChangelogTruncatedError(txID, oldest)
+	}
+
+	i := sort.Search(log.count, func(i int) bool {
+		return log.events[(log.start+i)%len(log.events)].TxID > txID
+	})
+	if i < log.count {
+		return log.events[(log.start+i)%len(log.events)], true, nil, nil
+	}
+	return CommitEvent{}, false, log.published, nil
+}
+
+// publish hands a commit to the subscribers and watchers, a commit that wrote
+// nothing is not an event.
+func (s *memStore) publish(record wal.Record) {
+	if len(record.Entries) > 0 {
+		s.changelog.append(newCommitEvent(record))
+		s.watchers.notify(record)
+	}
+}
+
+func newCommitEvent(record wal.Record) CommitEvent {
+	changes := make([]Change, len(record.Entries))
+	for i, entry := range record.Entries {
+		changes[i] = Change{
+			Key:      entry.Key,
+			Deleted:  entry.OperationType == operation.DELETE,
+			Value:    entry.Value,
+			ExpireAt: entry.ExpireAt,
+		}
+	}
+	return CommitEvent{TxID: record.TxID, CommittedAt: record.CommittedAt, Changes: changes}
+}
+
+type subscription struct {
+	ctx    context.Context
+	log    *changelog
+	cursor int
+}
+
+// Subscribe returns the commits made after fromTxID, or from now on with
+// SubscribeFromNow. To resume, subscribe again from the Cursor of the last
+// subscription.
+func (s *memStore) Subscribe(ctx context.Context, fromTxID int) (Subscription, error) {
+	if fromTxID == SubscribeFromNow {
+		s.rwMutex.RLock()
+		fromTxID = s.clock.Current()
+		s.rwMutex.RUnlock()
+	}
+
+	if _, _, _, err := s.changelog.after(fromTxID); err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return nil, err
+	}
+	return &subscription{ctx: ctx, log: s.changelog, cursor: fromTxID}, nil
+}
+
+func (sub *subscription) Next(ctx context.Context) (CommitEvent, error) {
+	for {
+		event, found, published, err := sub.log.after(sub.cursor)
+		if err != nil {
+			return CommitEvent{}, err
+		}
+		if found {
+			sub.cursor = event.TxID
+			return event, nil
+		}
+
+		select {
+		case <-published:
+		case <-ctx.Done():
+			return CommitEvent{}, ctx.Err()
+		case <-sub.ctx.Done():
+			return CommitEvent{}, sub.ctx.Err()
+		}
+	}
+}
+
+func (sub *subscription) Cursor() int {
+	return sub.cursor
+}
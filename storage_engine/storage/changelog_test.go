@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func TestMemStorage_Subscribe(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Events carry the full write set", func(t *testing.T) {
+		storage := NewMemStore()
+		sub, err := storage.Subscribe(ctx, SubscribeFromNow)
+		assert.NoError(t, err)
+
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		tx := storage.Tx()
+		assert.NoError(t, tx.Set(ctx, "b", 2))
+		assert.NoError(t, tx.Delete(ctx, "a"))
+		assert.NoError(t, tx.Commit(ctx))
+
+		event, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []Change{{Key: "a", Value: 1}}, event.Changes)
+		assert.False(t, event.CommittedAt.IsZero())
+
+		event, err = sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []Change{{Key: "a", Deleted: true}, {Key: "b", Value: 2}}, event.Changes)
+		assert.Equal(t, event.TxID, sub.Cursor())
+	})
+
+	t.Run("Next waits for the next commit", func(t *testing.T) {
+		storage := NewMemStore()
+		sub, err := storage.Subscribe(ctx, 0)
+		assert.NoError(t, err)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = storage.Set(ctx, "a", 1)
+		}()
+		event, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", event.Changes[0].Key)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = sub.Next(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Resume and truncation", func(t *testing.T) {
+		storage := NewMemStore(WithChangelogSize(2))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, storage.Set(ctx, "a", i))
+		}
+
+		var truncated *appCommon.ChangelogTruncatedError
+		_, err := storage.Subscribe(ctx, 0)
+		assert.ErrorAs(t, err, &truncated)
+		assert.Equal(t, 2, truncated.OldestTxID)
+
+		sub, err := storage.Subscribe(ctx, 2)
+		assert.NoError(t, err)
+		event, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, event.TxID)
+
+		// a slow subscriber is told instead of blocking writers
+		assert.NoError(t, storage.Set(ctx, "a", 3))
+		assert.NoError(t, storage.Set(ctx, "a", 4))
+		assert.NoError(t, storage.Set(ctx, "a", 5))
+		_, err = sub.Next(ctx)
+		assert.ErrorAs(t, err, &truncated)
+	})
+
+	t.Run("A restored store does not claim commits it never saw", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "store.snapshot")
+		walDir := filepath.Join(dir, "wal")
+		options := wal.Options{SyncPolicy: wal.SyncEveryCommit}
+
+		store, err := OpenMemStore(WithWAL(walDir, options))
+		assert.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, store.Set(ctx, "a", i))
+		}
+		snapshotTxID, err := store.Snapshot(ctx, path)
+		assert.NoError(t, err)
+		assert.NoError(t, store.Set(ctx, "a", 5))
+		assert.NoError(t, store.Close())
+
+		var truncated *appCommon.ChangelogTruncatedError
+		restored, err := LoadSnapshot(path)
+		assert.NoError(t, err)
+		_, err = restored.Subscribe(ctx, 2)
+		assert.ErrorAs(t, err, &truncated)
+		_, err = restored.Subscribe(ctx, snapshotTxID)
+		assert.NoError(t, err)
+
+		// the snapshot truncated the wal, so replaying it alone misses the same commits
+		replayed, err := OpenMemStore(WithWAL(walDir, options))
+		assert.NoError(t, err)
+		defer replayed.Close()
+		_, err = replayed.Subscribe(ctx, 2)
+		assert.ErrorAs(t, err, &truncated)
+		assert.Equal(t, snapshotTxID+1, truncated.OldestTxID)
+
+		sub, err := replayed.Subscribe(ctx, snapshotTxID)
+		assert.NoError(t, err)
+		event, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []Change{{Key: "a", Value: 5}}, event.Changes)
+	})
+
+	t.Run("Replayed commits can be resumed after a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		options := wal.Options{SyncPolicy: wal.SyncEveryCommit}
+		store, err := OpenMemStore(WithWAL(dir, options))
+		assert.NoError(t, err)
+		assert.NoError(t, store.Set(ctx, "a", 1))
+		assert.NoError(t, store.Set(ctx, "b", 2))
+		assert.NoError(t, store.Close())
+
+		restored, err := OpenMemStore(WithWAL(dir, options))
+		assert.NoError(t, err)
+		defer restored.Close()
+
+		sub, err := restored.Subscribe(ctx, 1)
+		assert.NoError(t, err)
+		event, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []Change{{Key: "b", Value: 2}}, event.Changes)
+	})
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/wal"
+	"time"
+)
+
+// currentVersion is the txID of the latest version of key, deletes included,
+// or 0 if the key has none. Callers must hold the lock.
+func (s *memStore) currentVersion(ctx context.Context, key string) int {
+	if !s.checkKeyExist(key) {
+		return 0
+	}
+	txID, err := s.data[key].GetLatestVersionForKey(ctx)
+	if err != nil {
+		return 0
+	}
+	return txID
+}
+
+// checkVersion fails with a VersionMismatchError unless key is at expected.
+// Callers must hold the lock.
+func (s *memStore) checkVersion(ctx context.Context, key string, expected int) error {
+	if current := s.currentVersion(ctx, key); current != expected {
+		err := appCommon.NewVersionMismatchError(key, expected, current)
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	return nil
+}
+
+func (s *memStore) CompareAndSwap(ctx context.Context, key string, expectedVersion int, value interface{}) error {
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	if err := s.checkVersion(ctx, key, expectedVersion); err != nil {
+		return err
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, value, time.Time{})})
+}
+
+func (s *memStore) SetIfAbsent(ctx context.Context, key string, value interface{}) error {
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	if s.checkKeyVisible(ctx, key) {
+		err := appCommon.NewVersionMismatchError(key, 0, s.currentVersion(ctx, key))
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, value, time.Time{})})
+}
+
+func (s *memStore) SetIfPresent(ctx context.Context, key string, value interface{}) error {
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	if !s.checkKeyVisible(ctx, key) {
+		s.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, value, time.Time{})})
+}
+
+func (s *memStore) DeleteIfVersion(ctx context.Context, key string, expectedVersion int) error {
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	if err := s.checkVersion(ctx, key, expectedVersion); err != nil {
+		return err
+	}
+	if !s.checkKeyVisible(ctx, key) {
+		s.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newDeleteEntry(key)})
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func latestTxID(t *testing.T, storage MemStorage, key string) int {
+	history, err := storage.History(context.Background(), key)
+	assert.NoError(t, err)
+	return history[len(history)-1].TxID
+}
+
+func TestMemStorage_ConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.CompareAndSwap(ctx, "a", 0, 1))
+		version := latestTxID(t, storage, "a")
+
+		assert.NoError(t, storage.CompareAndSwap(ctx, "a", version, 2))
+		var mismatch *appCommon.VersionMismatchError
+		err := storage.CompareAndSwap(ctx, "a", version, 3)
+		assert.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, latestTxID(t, storage, "a"), mismatch.Current)
+
+		value, _ := storage.Get(ctx, "a")
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("SetIfAbsent and SetIfPresent", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.ErrorIs(t, storage.SetIfPresent(ctx, "a", 1), appCommon.KeyDoesNotExist)
+		assert.NoError(t, storage.SetIfAbsent(ctx, "a", 1))
+
+		var mismatch *appCommon.VersionMismatchError
+		assert.ErrorAs(t, storage.SetIfAbsent(ctx, "a", 2), &mismatch)
+		assert.Equal(t, latestTxID(t, storage, "a"), mismatch.Current)
+		assert.NoError(t, storage.SetIfPresent(ctx, "a", 3))
+
+		// a deleted key is absent again
+		assert.NoError(t, storage.Delete(ctx, "a"))
+		assert.NoError(t, storage.SetIfAbsent(ctx, "a", 4))
+		value, _ := storage.Get(ctx, "a")
+		assert.Equal(t, 4, value)
+	})
+
+	t.Run("DeleteIfVersion", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		version := latestTxID(t, storage, "a")
+		assert.NoError(t, storage.Set(ctx, "a", 2))
+
+		var mismatch *appCommon.VersionMismatchError
+		assert.ErrorAs(t, storage.DeleteIfVersion(ctx, "a", version), &mismatch)
+		assert.NoError(t, storage.DeleteIfVersion(ctx, "a", mismatch.Current))
+		assert.ErrorIs(t, storage.DeleteIfVersion(ctx, "a", latestTxID(t, storage, "a")), appCommon.KeyDoesNotExist)
+	})
+
+	t.Run("A transaction commit moves the version", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		version := latestTxID(t, storage, "a")
+
+		tx := storage.Tx()
+		assert.NoError(t, tx.Set(ctx, "a", 2))
+		assert.NoError(t, tx.Commit(ctx))
+
+		var mismatch *appCommon.VersionMismatchError
+		assert.ErrorAs(t, storage.CompareAndSwap(ctx, "a", version, 3), &mismatch)
+	})
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemStorage_RemoveOldVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Without open transactions only the latest version is kept", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, storage.Set(ctx, "a", 2))
+		assert.NoError(t, storage.Set(ctx, "a", 3))
+
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+
+		history, err := storage.History(ctx, "a")
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+		assert.Equal(t, 3, history[0].Value)
+	})
+
+	t.Run("Versions read by an open transaction are kept", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, storage.Set(ctx, "a", 2))
+
+		tx := storage.Tx()
+		readTx := storage.ReadTx()
+		assert.NoError(t, storage.Set(ctx, "a", 3))
+		assert.NoError(t, storage.Set(ctx, "a", 4))
+
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+
+		history, err := storage.History(ctx, "a")
+		assert.NoError(t, err)
+		assert.Len(t, history, 3)
+
+		value, err := tx.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, value)
+		value, err = readTx.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, value)
+
+		assert.NoError(t, tx.Abort(ctx))
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		history, _ = storage.History(ctx, "a")
+		assert.Len(t, history, 3)
+
+		assert.NoError(t, readTx.Commit(ctx))
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		history, _ = storage.History(ctx, "a")
+		assert.Len(t, history, 1)
+	})
+
+	t.Run("Deleted keys are dropped once no snapshot can see them", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, storage.Set(ctx, "b", 1))
+		tx := storage.Tx()
+		assert.NoError(t, storage.Delete(ctx, "a"))
+
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		_, err := storage.History(ctx, "a")
+		assert.NoError(t, err)
+
+		assert.NoError(t, tx.Abort(ctx))
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		_, err = storage.History(ctx, "a")
+		assert.Error(t, err)
+
+		values, err := storage.Scan(ctx, "", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "b", Value: 1}}, values)
+
+		assert.NoError(t, storage.Set(ctx, "a", 2))
+		value, err := storage.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+}
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/index"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/wal"
+	"sort"
+	"time"
+)
+
+func (s *memStore) CreateIndex(ctx context.Context, name string, extract index.Func) error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if _, exist := s.indexes[name]; exist {
+		s.logger.WithContext(ctx).Errorln(appCommon.NewIndexAlreadyExistsError(name))
+		return appCommon.NewIndexAlreadyExistsError(name)
+	}
+
+	s.indexes[name] = s.buildIndex(ctx, name, extract)
+	s.logger.Infof("Index %s created", name)
+	return nil
+}
+
+func (s *memStore) CreateUniqueIndex(ctx context.Context, name string, extract index.Func) error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if _, exist := s.indexes[name]; exist {
+		s.logger.WithContext(ctx).Errorln(appCommon.NewIndexAlreadyExistsError(name))
+		return appCommon.NewIndexAlreadyExistsError(name)
+	}
+
+	idx := s.buildIndex(ctx, name, extract)
+	claimed := make(map[string]string)
+	var err error
+	s.keys.Ascend("", "", func(key string, _ struct{}) bool {
+		for _, tuple := range idx.Extract(s.data[key].GetCommitted(ctx)) {
+			from, _ := index.LookupBounds(tuple)
+			if holder, dup := claimed[from]; dup && holder != key {
+				err = appCommon.NewUniqueViolationError(name, key, holder)
+				return false
+			}
+			claimed[from] = key
+		}
+		return true
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+
+	s.indexes[name] = idx
+	s.uniqueIndexes[name] = struct{}{}
+	s.logger.Infof("Unique index %s created", name)
+	return nil
+}
+
+// buildIndex replays the versions still kept into a new index, so older
+// snapshots can query it as well. Callers must hold the write lock.
+func (s *memStore) buildIndex(ctx context.Context, name string, extract index.Func) index.Index {
+	idx := index.New(name, extract)
+	s.keys.Ascend("", "", func(key string, _ struct{}) bool {
+		for _, info := range s.data[key].History(ctx) {
+			if info.Visible {
+				idx.Set(key, info.Value, info.TxID)
+			} else {
+				idx.Delete(key, info.TxID)
+			}
+		}
+		return true
+	})
+	return idx
+}
+
+// checkUniqueIndexes fails if committing entries would leave two live keys
+// with the same tuple in a unique index. Callers must hold the write lock.
+func (s *memStore) checkUniqueIndexes(ctx context.Context, entries []wal.Entry) error {
+	if len(s.uniqueIndexes) == 0 {
+		return nil
+	}
+
+	writing := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		writing[entry.Key] = struct{}{}
+	}
+
+	now := time.Now()
+	for name := range s.uniqueIndexes {
+		idx := s.indexes[name]
+		claimed := make(map[string]string) // tuples taken by entries
+		for _, entry := range entries {
+			if entry.OperationType != operation.SET || expired(entry.ExpireAt, now) {
+				continue
+			}
+			for _, tuple := range idx.Extract(entry.Value) {
+				from, to := index.LookupBounds(tuple)
+				if holder, dup := claimed[from]; dup && holder != entry.Key {
+					return appCommon.NewUniqueViolationError(name, entry.Key, holder)
+				}
+				claimed[from] = entry.Key
+
+				// keys rewritten by entries are checked with their new value
+				conflict := ""
+				idx.Ascend(from, to, index.Latest, func(position, holder string) bool {
+					if _, rewritten := writing[holder]; rewritten || position != index.Position(tuple, holder) {
+						return true
+					}
+					if s.data[holder].GetCommitted(ctx) == nil {
+						return true
+					}
+					conflict = holder
+					return false
+				})
+				if conflict != "" {
+					return appCommon.NewUniqueViolationError(name, entry.Key, conflict)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memStore) DropIndex(ctx context.Context, name string) error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if _, exist := s.indexes[name]; !exist {
+		s.logger.WithContext(ctx).Errorln(appCommon.NewIndexDoesNotExistError(name))
+		return appCommon.NewIndexDoesNotExistError(name)
+	}
+	delete(s.indexes, name)
+	delete(s.uniqueIndexes, name)
+	return nil
+}
+
+func (s *memStore) LookupIndex(ctx context.Context, name string, tuple index.Tuple) ([]KeyValue, error) {
+	from, to := index.LookupBounds(tuple)
+	return s.queryIndex(ctx, name, from, to, 0)
+}
+
+func (s *memStore) RangeIndex(ctx context.Context, name string, start, end index.Tuple, limit int) ([]KeyValue, error) {
+	from, to := index.RangeBounds(start, end)
+	return s.queryIndex(ctx, name, from, to, limit)
+}
+
+func (s *memStore) queryIndex(ctx context.Context, name, from, to string, limit int) ([]KeyValue, error) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	idx, exist := s.indexes[name]
+	if !exist {
+		return nil, appCommon.NewIndexDoesNotExistError(name)
+	}
+	return s.indexScan(ctx, idx, from, to, index.Latest, time.Time{}, nil, limit), nil
+}
+
+func (tx *memTx) LookupIndex(ctx context.Context, name string, tuple index.Tuple) ([]KeyValue, error) {
+	from, to := index.LookupBounds(tuple)
+	return tx.queryIndex(ctx, name, from, to, 0)
+}
+
+func (tx *memTx) RangeIndex(ctx context.Context, name string, start, end index.Tuple, limit int) ([]KeyValue, error) {
+	from, to := index.RangeBounds(start, end)
+	return tx.queryIndex(ctx, name, from, to, limit)
+}
+
+func (tx *memTx) queryIndex(ctx context.Context, name, from, to string, limit int) ([]KeyValue, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+	idx, exist := tx.memStore.indexes[name]
+	if !exist {
+		return nil, appCommon.NewIndexDoesNotExistError(name)
+	}
+
+	// an index range does not map to a key range, so under Serializable any
+	// later commit counts as a possible phantom
+	tx.trackReadRange("", "")
+
+	pending := tx.pendingOperations()
+	return tx.memStore.indexScan(ctx, idx, from, to, tx.txID, tx.startedAt, pending, limit), nil
+}
+
+func (tx *readTx) LookupIndex(ctx context.Context, name string, tuple index.Tuple) ([]KeyValue, error) {
+	from, to := index.LookupBounds(tuple)
+	return tx.queryIndex(ctx, name, from, to, 0)
+}
+
+func (tx *readTx) RangeIndex(ctx context.Context, name string, start, end index.Tuple, limit int) ([]KeyValue, error) {
+	from, to := index.RangeBounds(start, end)
+	return tx.queryIndex(ctx, name, from, to, limit)
+}
+
+func (tx *readTx) queryIndex(ctx context.Context, name, from, to string, limit int) ([]KeyValue, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	idx, exist := tx.memStore.indexes[name]
+	if !exist {
+		return nil, appCommon.NewIndexDoesNotExistError(name)
+	}
+	return tx.memStore.indexScan(ctx, idx, from, to, tx.snapshot, tx.startedAt, nil, limit), nil
+}
+
+type indexHit struct {
+	position string
+	KeyValue
+}
+
+// indexScan returns the keys of idx in [from, to) at the snapshot of txID
+// taken at time at (index.Latest for the committed state), with the pending
+// operations of a transaction replacing what the snapshot holds for their
+// keys. A key matching several times is returned once, at its first position.
+// Callers must hold at least the read lock.
+func (s *memStore) indexScan(ctx context.Context, idx index.Index, from, to string, txID int, at time.Time, pending map[string]operation.Operation, limit int) []KeyValue {
+	hits := make([]indexHit, 0)
+	seen := make(map[string]struct{})
+	idx.Ascend(from, to, txID, func(position, key string) bool {
+		if _, overwritten := pending[key]; overwritten || !s.checkKeyExist(key) {
+			return true
+		}
+		var value interface{}
+		if txID == index.Latest {
+			value = s.data[key].GetCommitted(ctx)
+		} else {
+			value = s.data[key].GetValueBeforeTransaction(ctx, txID, at)
+		}
+		if value == nil {
+			return true
+		}
+		hits = append(hits, indexHit{position: position, KeyValue: KeyValue{Key: key, Value: value}})
+		seen[key] = struct{}{}
+		// pending writes can only add hits in front, never remove these
+		return limit <= 0 || len(seen) < limit
+	})
+
+	for key, op := range pending {
+		value, ok := pendingValue(op, time.Now())
+		if !ok {
+			continue
+		}
+		for _, position := range idx.Positions(key, value) {
+			if position >= from && (to == "" || position < to) {
+				hits = append(hits, indexHit{position: position, KeyValue: KeyValue{Key: key, Value: value}})
+			}
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].position < hits[j].position })
+
+	result := make([]KeyValue, 0, len(hits))
+	returned := make(map[string]struct{}, len(hits))
+	for _, hit := range hits {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if _, dup := returned[hit.Key]; dup {
+			continue
+		}
+		returned[hit.Key] = struct{}{}
+		result = append(result, hit.KeyValue)
+	}
+	return result
+}
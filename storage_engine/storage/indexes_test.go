@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/storage_engine/index"
+)
+
+func byCity(value interface{}) []index.Tuple {
+	user, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return []index.Tuple{{user["city"], user["age"]}}
+}
+
+func newUser(city string, age int) map[string]interface{} {
+	return map[string]interface{}{"city": city, "age": age}
+}
+
+func TestMemStorage_Index(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Index follows committed writes", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "u1", newUser("Hanoi", 30)))
+		assert.NoError(t, storage.CreateIndex(ctx, "city", byCity))
+		assert.Error(t, storage.CreateIndex(ctx, "city", byCity))
+
+		assert.NoError(t, storage.Set(ctx, "u2", newUser("Hanoi", 20)))
+		assert.NoError(t, storage.Set(ctx, "u3", newUser("Hue", 25)))
+		assert.NoError(t, storage.Set(ctx, "skipped", "not a user"))
+
+		values, err := storage.LookupIndex(ctx, "city", index.Tuple{"Hanoi"})
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "u2", Value: newUser("Hanoi", 20)}, {Key: "u1", Value: newUser("Hanoi", 30)}}, values)
+
+		assert.NoError(t, storage.Set(ctx, "u1", newUser("Hue", 30)))
+		assert.NoError(t, storage.Delete(ctx, "u2"))
+
+		values, err = storage.LookupIndex(ctx, "city", index.Tuple{"Hanoi"})
+		assert.NoError(t, err)
+		assert.Empty(t, values)
+
+		values, err = storage.RangeIndex(ctx, "city", index.Tuple{"Hue", 26}, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "u1", Value: newUser("Hue", 30)}}, values)
+
+		assert.NoError(t, storage.DropIndex(ctx, "city"))
+		_, err = storage.LookupIndex(ctx, "city", index.Tuple{"Hue"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Transaction queries its snapshot and own writes", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.CreateIndex(ctx, "city", byCity))
+		assert.NoError(t, storage.Set(ctx, "u1", newUser("Hanoi", 30)))
+		assert.NoError(t, storage.Set(ctx, "u2", newUser("Hanoi", 20)))
+
+		tx := storage.Tx()
+		readTx := storage.ReadTx()
+		assert.NoError(t, storage.Set(ctx, "u3", newUser("Hanoi", 40)))
+		assert.NoError(t, storage.Delete(ctx, "u1"))
+
+		assert.NoError(t, tx.Set(ctx, "u4", newUser("Hanoi", 10)))
+		assert.NoError(t, tx.Set(ctx, "u2", newUser("Hue", 20)))
+
+		values, err := tx.LookupIndex(ctx, "city", index.Tuple{"Hanoi"})
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "u4", Value: newUser("Hanoi", 10)}, {Key: "u1", Value: newUser("Hanoi", 30)}}, values)
+
+		values, err = tx.RangeIndex(ctx, "city", index.Tuple{"Hanoi"}, nil, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "u4", Value: newUser("Hanoi", 10)}}, values)
+
+		values, err = readTx.LookupIndex(ctx, "city", index.Tuple{"Hanoi"})
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "u2", Value: newUser("Hanoi", 20)}, {Key: "u1", Value: newUser("Hanoi", 30)}}, values)
+
+		assert.NoError(t, tx.Commit(ctx))
+		values, err = storage.LookupIndex(ctx, "city", index.Tuple{"Hanoi"})
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "u4", Value: newUser("Hanoi", 10)}, {Key: "u3", Value: newUser("Hanoi", 40)}}, values)
+	})
+
+	t.Run("Index entries are collected with old versions", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.CreateIndex(ctx, "city", byCity))
+		assert.NoError(t, storage.Set(ctx, "u1", newUser("Hanoi", 30)))
+		tx := storage.Tx()
+		assert.NoError(t, storage.Delete(ctx, "u1"))
+
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		values, _ := tx.LookupIndex(ctx, "city", index.Tuple{"Hanoi"})
+		assert.Len(t, values, 1)
+
+		assert.NoError(t, tx.Abort(ctx))
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		assert.Zero(t, storage.(*memStore).indexes["city"].Len())
+	})
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemStorage_KeysAndHistory(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemStore()
+
+	assert.NoError(t, storage.Set(ctx, "user:2", "bob"))
+	assert.NoError(t, storage.Set(ctx, "user:1", "alice"))
+	assert.NoError(t, storage.Set(ctx, "order:1", 10))
+	assert.NoError(t, storage.Set(ctx, "user:3", "carol"))
+	assert.NoError(t, storage.Delete(ctx, "user:3"))
+
+	keys, err := storage.Keys(ctx, "user:*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+
+	keys, err = storage.Keys(ctx, "*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order:1", "user:1", "user:2"}, keys)
+
+	_, err = storage.Keys(ctx, "[")
+	assert.Error(t, err)
+
+	history, err := storage.History(ctx, "user:3")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.True(t, history[0].Visible)
+	assert.Equal(t, "carol", history[0].Value)
+	assert.False(t, history[1].Visible)
+	assert.Greater(t, history[1].TxID, history[0].TxID)
+
+	_, err = storage.History(ctx, "missing")
+	assert.Equal(t, appCommon.KeyDoesNotExist, err)
+}
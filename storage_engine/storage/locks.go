@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LockWaitPolicy says what a writer does about a key locked by another
+// transaction with Lock or GetForUpdate.
+type LockWaitPolicy int
+
+const (
+	// Block waits for the lock to be released until the context of the call is
+	// done, then fails with *appCommon.KeyLockedError wrapping ctx.Err(). A
+	// waiting transaction also gives up when it expires, wrapping
+	// *appCommon.TxExpiredError, and when the holder waits for one of its own
+	// locks, wrapping appCommon.Deadlock. A holder past its deadline is aborted
+	// instead of waited for.
+	Block LockWaitPolicy = iota
+	// FailFast fails with *appCommon.KeyLockedError right away.
+	FailFast
+)
+
+type keyLock struct {
+	txID     int
+	lockedAt int // clock value when taken, the holder reads the key from there
+}
+
+// lockTable holds the pessimistic key locks. It has its own lock so waiting
+// for a key never holds the store lock.
+type lockTable struct {
+	mutex    sync.Mutex
+	owners   map[string]keyLock
+	held     map[int][]string // txID -> locked keys
+	waiting  map[int]int      // txID -> holder it waits for
+	released chan struct{}    // closed and replaced whenever locks are released
+}
+
+func newLockTable() *lockTable {
+	return &lockTable{
+		owners:   make(map[string]keyLock),
+		held:     make(map[int][]string),
+		waiting:  make(map[int]int),
+		released: make(chan struct{}),
+	}
+}
+
+// tryAcquire locks every key of keys for txID or, if one of them is held by
+// another transaction, none. In that case it returns the key, its holder and a
+// channel closed by the next release.
+func (locks *lockTable) tryAcquire(txID int, keys []string, lockedAt int) (string, int, <-chan struct{}, bool) {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+
+	for _, key := range keys {
+		if owner, ok := locks.owners[key]; ok && owner.txID != txID {
+			return key, owner.txID, locks.released, false
+		}
+	}
+	for _, key := range keys {
+		if _, ok := locks.owners[key]; !ok {
+			locks.owners[key] = keyLock{txID: txID, lockedAt: lockedAt}
+			locks.held[txID] = append(locks.held[txID], key)
+		}
+	}
+	return "", 0, nil, true
+}
+
+// lockedByOther is tryAcquire without acquiring, for writers not taking locks.
+func (locks *lockTable) lockedByOther(txID int, keys []string) (string, int, <-chan struct{}, bool) {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+
+	for _, key := range keys {
+		if owner, ok := locks.owners[key]; ok && owner.txID != txID {
+			return key, owner.txID, locks.released, true
+		}
+	}
+	return "", 0, nil, false
+}
+
+func (locks *lockTable) isLocked(key string) bool {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+	_, ok := locks.owners[key]
+	return ok
+}
+
+// readAt is the txID that txID reads key at: its snapshot or, for a key it
+// has locked since, the clock value it locked the key at.
+func (locks *lockTable) readAt(txID int, key string) int {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+
+	if owner, ok := locks.owners[key]; ok && owner.txID == txID {
+		return max(txID, owner.lockedAt)
+	}
+	return txID
+}
+
+// startWaiting records that txID waits for holder, unless holder already
+// waits, directly or not, for txID: then waiting would never end and it
+// returns false.
+func (locks *lockTable) startWaiting(txID, holder int) bool {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+
+	for next, ok := holder, true; ok; next, ok = locks.waiting[next] {
+		if next == txID {
+			return false
+		}
+	}
+	locks.waiting[txID] = holder
+	return true
+}
+
+func (locks *lockTable) stopWaiting(txID int) {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+	delete(locks.waiting, txID)
+}
+
+func (locks *lockTable) release(txID int) {
+	locks.mutex.Lock()
+	defer locks.mutex.Unlock()
+
+	keys, ok := locks.held[txID]
+	if !ok {
+		return
+	}
+	for _, key := range keys {
+		delete(locks.owners, key)
+	}
+	delete(locks.held, txID)
+	close(locks.released)
+	locks.released = make(chan struct{})
+}
+
+// lockForWrite takes the store write lock once no key of keys is locked by a
+// transaction other than committer (nil for a write outside transactions),
+// waiting or not as policy says. On success the caller must release the write
+// lock.
+func (s *memStore) lockForWrite(ctx context.Context, committer *memTx, policy LockWaitPolicy, keys ...string) error {
+	txID := noTransaction
+	if committer != nil {
+		txID = committer.txID
+	}
+	for {
+		s.rwMutex.Lock()
+		key, holder, released, locked := s.locks.lockedByOther(txID, keys)
+		if !locked {
+			return nil
+		}
+		if s.reapLockHolder(holder, time.Now()) {
+			s.rwMutex.Unlock()
+			continue
+		}
+		holderDeadline := s.transactionDeadline[holder]
+		s.rwMutex.Unlock()
+
+		if err := s.waitForLock(ctx, committer, policy, key, holder, holderDeadline, released); err != nil {
+			s.logger.WithContext(ctx).Errorln(err)
+			return err
+		}
+	}
+}
+
+// reapLockHolder aborts holder if it is past its deadline, which releases its
+// locks. Callers must hold the write lock.
+func (s *memStore) reapLockHolder(holder int, now time.Time) bool {
+	deadline, ok := s.transactionDeadline[holder]
+	if !ok || now.Before(deadline) {
+		return false
+	}
+	s.logger.Infof("Transaction %d holding locks expired at %v, aborting it", holder, deadline)
+	s.removeTransaction(holder)
+	return true
+}
+
+// waitForLock waits until the locks of holder may have changed: a release, or
+// the holder reaching its deadline, after which the caller reaps it. The wait
+// fails once ctx is done, once waiter (nil outside transactions) expires, or
+// right away if holder waits for waiter.
+func (s *memStore) waitForLock(ctx context.Context, waiter *memTx, policy LockWaitPolicy, key string, holder int, holderDeadline time.Time, released <-chan struct{}) error {
+	if policy == FailFast {
+		return appCommon.NewKeyLockedError(key, holder, nil)
+	}
+
+	var holderExpired, waiterExpired <-chan time.Time
+	var waiterDone <-chan struct{}
+	if !holderDeadline.IsZero() {
+		timer := time.NewTimer(time.Until(holderDeadline))
+		defer timer.Stop()
+		holderExpired = timer.C
+	}
+	if waiter != nil {
+		if !s.locks.startWaiting(waiter.txID, holder) {
+			return appCommon.NewKeyLockedError(key, holder, appCommon.Deadlock)
+		}
+		defer s.locks.stopWaiting(waiter.txID)
+
+		if !waiter.deadline.IsZero() {
+			timer := time.NewTimer(time.Until(waiter.deadline))
+			defer timer.Stop()
+			waiterExpired = timer.C
+		}
+		if waiter.ctx != nil {
+			waiterDone = waiter.ctx.Done()
+		}
+	}
+
+	select {
+	case <-released:
+		return nil
+	case <-holderExpired:
+		return nil
+	case <-ctx.Done():
+		return appCommon.NewKeyLockedError(key, holder, ctx.Err())
+	case <-waiterExpired:
+		return appCommon.NewKeyLockedError(key, holder, appCommon.NewTxExpiredError(waiter.txID))
+	case <-waiterDone:
+		return appCommon.NewKeyLockedError(key, holder, appCommon.NewTxExpiredError(waiter.txID))
+	}
+}
+
+// Lock locks keys for the outermost transaction until it commits or aborts.
+// Other writers of these keys wait or fail as their LockWaitPolicy says, and
+// the transaction reads them as committed when it got the lock instead of at
+// its snapshot, so committing them does not conflict. Keys are locked all at
+// once, a lock held by another transaction is waited for as the policy of this
+// one says. Two transactions that each lock a key and then write the key of
+// the other would wait for each other forever, so the second one to wait fails
+// with appCommon.Deadlock instead and should abort.
+func (tx *memTx) Lock(ctx context.Context, keys ...string) error {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+	root := tx.root()
+
+	for {
+		tx.memStore.rwMutex.RLock()
+		if err := tx.checkOpen(ctx); err != nil {
+			tx.memStore.rwMutex.RUnlock()
+			return err
+		}
+		// no commit is half applied under the read lock, so the clock is
+		// exactly what is visible
+		key, holder, released, ok := tx.memStore.locks.tryAcquire(root.txID, keys, tx.memStore.clock.Current())
+		holderDeadline := tx.memStore.transactionDeadline[holder]
+		tx.memStore.rwMutex.RUnlock()
+		if ok {
+			tx.memStore.logger.Infof("Transaction %d locked %v", root.txID, keys)
+			return nil
+		}
+
+		if !holderDeadline.IsZero() && !time.Now().Before(holderDeadline) {
+			tx.memStore.rwMutex.Lock()
+			tx.memStore.reapLockHolder(holder, time.Now())
+			tx.memStore.rwMutex.Unlock()
+			continue
+		}
+		if err := tx.memStore.waitForLock(ctx, root, root.lockWait, key, holder, holderDeadline, released); err != nil {
+			tx.memStore.logger.WithContext(ctx).Errorln(err)
+			return err
+		}
+	}
+}
+
+// GetForUpdate locks key and returns its latest committed value, or the
+// pending write of the transaction if it has one.
+func (tx *memTx) GetForUpdate(ctx context.Context, key string) (interface{}, error) {
+	if err := tx.Lock(ctx, key); err != nil {
+		return nil, err
+	}
+	return tx.Get(ctx, key)
+}
+
+func (tx *readTx) Lock(ctx context.Context, keys ...string) error {
+	return tx.rejectWrite(ctx)
+}
+
+func (tx *readTx) GetForUpdate(ctx context.Context, key string) (interface{}, error) {
+	return nil, tx.rejectWrite(ctx)
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemTx_Lock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Locked increments never conflict", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "counter", 0))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tx := storage.Tx()
+				value, err := tx.GetForUpdate(ctx, "counter")
+				assert.NoError(t, err)
+				assert.NoError(t, tx.Set(ctx, "counter", value.(int)+1))
+				assert.NoError(t, tx.Commit(ctx))
+			}()
+		}
+		wg.Wait()
+
+		value, _ := storage.Get(ctx, "counter")
+		assert.Equal(t, 20, value)
+	})
+
+	t.Run("Writers wait for the lock", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.Tx()
+		assert.NoError(t, tx.Lock(ctx, "a", "b"))
+
+		written := make(chan error)
+		go func() {
+			written <- storage.Set(ctx, "a", "writer")
+		}()
+		select {
+		case <-written:
+			t.Fatal("the write did not wait for the lock")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		assert.NoError(t, tx.Set(ctx, "a", "holder"))
+		assert.NoError(t, tx.Commit(ctx))
+		assert.NoError(t, <-written)
+		value, _ := storage.Get(ctx, "a")
+		assert.Equal(t, "writer", value)
+	})
+
+	t.Run("Lock waits are bounded by the context", func(t *testing.T) {
+		storage := NewMemStore()
+		holder := storage.Tx()
+		assert.NoError(t, holder.Lock(ctx, "a"))
+
+		other := storage.Tx()
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		var locked *appCommon.KeyLockedError
+		assert.ErrorAs(t, other.Lock(timeout, "a"), &locked)
+		assert.Equal(t, holder.ID(), locked.TxID)
+		assert.ErrorIs(t, locked, context.DeadlineExceeded)
+
+		// an abort releases the locks too
+		assert.NoError(t, holder.Abort(ctx))
+		assert.NoError(t, other.Lock(ctx, "a"))
+		assert.NoError(t, other.Abort(ctx))
+	})
+
+	t.Run("Fail fast", func(t *testing.T) {
+		storage := NewMemStore(WithLockWait(FailFast))
+		holder := storage.Tx()
+		assert.NoError(t, holder.Lock(ctx, "a"))
+
+		var locked *appCommon.KeyLockedError
+		assert.ErrorAs(t, storage.Set(ctx, "a", 1), &locked)
+
+		other := storage.Tx()
+		assert.NoError(t, other.Set(ctx, "a", 2))
+		assert.ErrorAs(t, other.Commit(ctx), &locked)
+		assert.ErrorAs(t, other.Lock(ctx, "a"), &locked)
+		assert.NoError(t, other.Abort(ctx))
+
+		blocking := storage.Tx(WithTxLockWait(Block))
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, blocking.Lock(timeout, "a"), context.DeadlineExceeded)
+		assert.NoError(t, blocking.Abort(ctx))
+		assert.NoError(t, holder.Abort(ctx))
+	})
+
+	t.Run("Locks of an expired holder do not block", func(t *testing.T) {
+		storage := NewMemStore()
+		holder := storage.Tx(WithTimeout(20 * time.Millisecond))
+		assert.NoError(t, holder.Lock(ctx, "a"))
+
+		// nothing reaps the holder in between, the writer does
+		assert.NoError(t, storage.Set(ctx, "a", "writer"))
+		var expired *appCommon.TxExpiredError
+		assert.ErrorAs(t, holder.Commit(ctx), &expired)
+
+		holder = storage.Tx(WithTimeout(20 * time.Millisecond))
+		assert.NoError(t, holder.Lock(ctx, "a"))
+		other := storage.Tx()
+		assert.NoError(t, other.Lock(ctx, "a"))
+		assert.NoError(t, other.Abort(ctx))
+	})
+
+	t.Run("Lock waits are bounded by the transaction deadline", func(t *testing.T) {
+		storage := NewMemStore()
+		holder := storage.Tx()
+		assert.NoError(t, holder.Lock(ctx, "a"))
+
+		waiter := storage.Tx(WithTimeout(20 * time.Millisecond))
+		err := waiter.Lock(ctx, "a")
+		var locked *appCommon.KeyLockedError
+		var expired *appCommon.TxExpiredError
+		assert.ErrorAs(t, err, &locked)
+		assert.ErrorAs(t, err, &expired)
+		assert.Equal(t, waiter.ID(), expired.TxID)
+		assert.NoError(t, holder.Abort(ctx))
+	})
+
+	t.Run("Writing the key locked by a waiting transaction is a deadlock", func(t *testing.T) {
+		storage := NewMemStore()
+		first, second := storage.Tx(), storage.Tx()
+		assert.NoError(t, first.Lock(ctx, "a"))
+		assert.NoError(t, second.Lock(ctx, "b"))
+		assert.NoError(t, first.Set(ctx, "b", 1))
+		assert.NoError(t, second.Set(ctx, "a", 2))
+
+		committed := make(chan error)
+		go func() {
+			committed <- first.Commit(ctx)
+		}()
+		locks := storage.(*memStore).locks
+		assert.Eventually(t, func() bool {
+			locks.mutex.Lock()
+			defer locks.mutex.Unlock()
+			return locks.waiting[first.ID()] == second.ID()
+		}, time.Second, time.Millisecond)
+
+		err := second.Commit(ctx)
+		assert.ErrorIs(t, err, appCommon.Deadlock)
+		assert.NoError(t, second.Abort(ctx))
+		assert.NoError(t, <-committed)
+
+		value, _ := storage.Get(ctx, "b")
+		assert.Equal(t, 1, value)
+	})
+
+	t.Run("Read-only transactions cannot lock", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.ReadTx()
+		var readOnly *appCommon.ReadOnlyTxError
+		assert.ErrorAs(t, tx.Lock(ctx, "a"), &readOnly)
+		assert.NoError(t, tx.Abort(ctx))
+	})
+}
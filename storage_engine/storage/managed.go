@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"in-memory-storage-engine/appCommon"
+	"math/rand/v2"
+	"time"
+)
+
+// Update runs fn in a new transaction and commits it. When the commit conflicts
+// the transaction is aborted and fn runs again on a fresh snapshot, up to
+// WithMaxAttempts times with a growing backoff in between, so fn must not have
+// side effects outside the transaction. If fn returns an error or panics the
+// transaction is aborted and the error (or panic) is passed on. fn must not
+// commit or abort tx itself.
+func (s *memStore) Update(ctx context.Context, fn func(tx MemTx) error, opts ...TxOption) error {
+	options := newTxOptions(opts)
+	backoff := options.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := s.runOnce(ctx, fn, opts)
+		if err == nil || !isTxConflict(err) || attempt >= options.maxAttempts {
+			return err
+		}
+
+		s.logger.Infof("Retrying conflicting transaction, attempt %d of %d", attempt+1, options.maxAttempts)
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// View runs fn in a read-only transaction, which never conflicts.
+func (s *memStore) View(ctx context.Context, fn func(tx MemTx) error) error {
+	// the transaction ends with ctx, so a hanging fn does not pin its snapshot
+	tx := s.Tx(ReadOnly(), WithContext(ctx))
+	defer tx.Abort(ctx)
+	return fn(tx)
+}
+
+func (s *memStore) runOnce(ctx context.Context, fn func(tx MemTx) error, opts []TxOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// the transaction ends with ctx, unless opts bind it to another context
+	tx := s.Tx(append([]TxOption{WithContext(ctx)}, opts...)...)
+	committed := false
+	// a failed commit leaves the transaction open as well
+	defer func() {
+		if !committed {
+			_ = tx.Abort(ctx)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func isTxConflict(err error) bool {
+	var conflict *appCommon.TxConflictError
+	return errors.As(err, &conflict)
+}
+
+// sleep waits between d/2 and d, so concurrent retries spread out.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d/2 + rand.N(d/2+1))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
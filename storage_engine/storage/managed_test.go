@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemStorage_Update(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("A conflicting transaction is run again", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "counter", 0))
+
+		attempts := 0
+		err := storage.Update(ctx, func(tx MemTx) error {
+			attempts++
+			value, err := tx.Get(ctx, "counter")
+			if err != nil {
+				return err
+			}
+			if attempts == 1 {
+				// someone else increments in between
+				assert.NoError(t, storage.Set(ctx, "counter", value.(int)+1))
+			}
+			return tx.Set(ctx, "counter", value.(int)+1)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+
+		value, _ := storage.Get(ctx, "counter")
+		assert.Equal(t, 2, value)
+		assert.Empty(t, storage.Transactions(ctx))
+	})
+
+	t.Run("Attempts are bounded", func(t *testing.T) {
+		storage := NewMemStore()
+		attempts := 0
+		err := storage.Update(ctx, func(tx MemTx) error {
+			attempts++
+			assert.NoError(t, storage.Set(ctx, "a", attempts))
+			return tx.Set(ctx, "a", 0)
+		}, WithMaxAttempts(3), WithBackoff(time.Microsecond))
+
+		var conflict *appCommon.TxConflictError
+		assert.ErrorAs(t, err, &conflict)
+		assert.Equal(t, 3, attempts)
+		assert.Empty(t, storage.Transactions(ctx))
+	})
+
+	t.Run("A closure error or panic aborts", func(t *testing.T) {
+		storage := NewMemStore()
+		failure := errors.New("failure")
+		err := storage.Update(ctx, func(tx MemTx) error {
+			assert.NoError(t, tx.Set(ctx, "a", 1))
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		assert.Panics(t, func() {
+			_ = storage.Update(ctx, func(tx MemTx) error {
+				assert.NoError(t, tx.Set(ctx, "a", 1))
+				panic("boom")
+			})
+		})
+		assert.Empty(t, storage.Transactions(ctx))
+		_, err = storage.Get(ctx, "a")
+		assert.ErrorIs(t, err, appCommon.KeyDoesNotExist)
+	})
+
+	t.Run("View is read-only", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		err := storage.View(ctx, func(tx MemTx) error {
+			value, err := tx.Get(ctx, "a")
+			assert.Equal(t, 1, value)
+			assert.NoError(t, err)
+			return tx.Set(ctx, "a", 2)
+		})
+		var readOnly *appCommon.ReadOnlyTxError
+		assert.ErrorAs(t, err, &readOnly)
+	})
+}
@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/version"
+	"in-memory-storage-engine/storage_engine/wal"
+	"reflect"
+	"sort"
+	"sync/atomic"
+)
+
+type EvictionPolicy int
+
+const (
+	// NoEviction rejects writes with appCommon.OutOfMemory once the limit is hit.
+	NoEviction EvictionPolicy = iota
+	// EvictLRU evicts the least recently used key.
+	EvictLRU
+	// EvictLFU evicts the least frequently used key.
+	EvictLFU
+	// EvictRandom evicts any key.
+	EvictRandom
+	// EvictNearestTTL evicts the key that expires first, keys without a TTL
+	// are never evicted.
+	EvictNearestTTL
+)
+
+// evictionSamples is how many keys are compared to pick each one to evict,
+// the same approximation Redis uses instead of a full ordering.
+const evictionSamples = 16
+
+// Estimated fixed costs on top of the bytes of keys and values: the map entry
+// and B-tree item of a key, the valueVersion struct and pointer of a version.
+const (
+	keyOverhead     = 64
+	versionOverhead = 64
+)
+
+type MemoryUsage struct {
+	Used      int64
+	Limit     int64
+	Keys      int
+	Evictions int
+}
+
+// memoryAccounting is only kept up to date when a limit is set. Sizes change
+// under the store write lock, usage counters are bumped by readers too.
+type memoryAccounting struct {
+	limit     int64
+	policy    EvictionPolicy
+	used      int64
+	keys      map[string]*keyUsage
+	ticks     atomic.Uint64
+	evictions int
+}
+
+type keyUsage struct {
+	size     int64
+	lastUsed atomic.Uint64
+	hits     atomic.Uint64
+}
+
+func newMemoryAccounting() *memoryAccounting {
+	return &memoryAccounting{
+		keys: make(map[string]*keyUsage),
+	}
+}
+
+func (s *memStore) MemoryUsage(ctx context.Context) MemoryUsage {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	return MemoryUsage{
+		Used:      s.memory.used,
+		Limit:     s.memory.limit,
+		Keys:      len(s.memory.keys),
+		Evictions: s.memory.evictions,
+	}
+}
+
+// accountVersion adds a new version of key holding value (nil for a delete).
+// Callers must hold the write lock.
+func (s *memStore) accountVersion(key string, value interface{}) {
+	if s.memory.limit <= 0 {
+		return
+	}
+	usage, exist := s.memory.keys[key]
+	if !exist {
+		usage = &keyUsage{size: int64(len(key)) + keyOverhead}
+		s.memory.keys[key] = usage
+		s.memory.used += usage.size
+	}
+	size := versionOverhead + sizeOf(value)
+	usage.size += size
+	s.memory.used += size
+	s.touchKey(key)
+}
+
+// recountKey recomputes the size of key after some of its versions were
+// removed. Callers must hold the write lock.
+func (s *memStore) recountKey(ctx context.Context, key string) {
+	usage, exist := s.memory.keys[key]
+	if s.memory.limit <= 0 || !exist {
+		return
+	}
+	size := int64(len(key)) + keyOverhead
+	for _, info := range s.data[key].History(ctx) {
+		size += versionOverhead + sizeOf(info.Value)
+	}
+	s.memory.used += size - usage.size
+	usage.size = size
+}
+
+func (s *memStore) forgetKeyUsage(key string) {
+	if usage, exist := s.memory.keys[key]; exist {
+		s.memory.used -= usage.size
+		delete(s.memory.keys, key)
+	}
+}
+
+// touchKey records a use of key for LRU and LFU. Callers must hold at least
+// the read lock.
+func (s *memStore) touchKey(key string) {
+	if usage, exist := s.memory.keys[key]; exist {
+		usage.lastUsed.Store(s.memory.ticks.Add(1))
+		usage.hits.Add(1)
+	}
+}
+
+// reserveMemory makes room for the values entries write, first by collecting
+// old versions and then by evicting whole keys. An evicted key keeps the
+// versions other open transactions and snapshots still read, so only keys
+// whose eviction frees memory right away are picked. Callers must hold the
+// write lock.
+func (s *memStore) reserveMemory(ctx context.Context, committer int, entries []wal.Entry) error {
+	if s.memory.limit <= 0 {
+		return nil
+	}
+
+	writing := make(map[string]bool, len(entries))
+	needed := int64(0)
+	for _, entry := range entries {
+		if entry.OperationType != operation.SET {
+			continue
+		}
+		writing[entry.Key] = true
+		needed += versionOverhead + sizeOf(entry.Value)
+		if !s.checkKeyExist(entry.Key) {
+			needed += int64(len(entry.Key)) + keyOverhead
+		}
+	}
+	if needed == 0 || s.memory.used+needed <= s.memory.limit {
+		return nil
+	}
+
+	if err := s.removeOldVersions(ctx); err != nil {
+		return err
+	}
+	if s.memory.used+needed <= s.memory.limit {
+		return nil
+	}
+	if s.memory.policy == NoEviction {
+		return appCommon.OutOfMemory
+	}
+
+	snapshots := s.openSnapshots(committer)
+	victims := make([]string, 0)
+	freed := int64(0)
+	misses := 0
+	for s.memory.used-freed+needed > s.memory.limit {
+		key, ok := s.pickEvictionVictim(writing)
+		// keys read by open snapshots cannot be freed, give up on a store
+		// full of them instead of walking every key
+		if !ok || misses >= evictionSamples {
+			return appCommon.OutOfMemory
+		}
+		writing[key] = true
+		if gain := s.evictionGain(ctx, committer, key, snapshots); gain > 0 {
+			victims = append(victims, key)
+			freed += gain
+		} else {
+			misses++
+		}
+	}
+	sort.Strings(victims)
+
+	evictions := make([]wal.Entry, 0, len(victims))
+	for _, key := range victims {
+		evictions = append(evictions, newDeleteEntry(key))
+	}
+	// deletes need no memory, so this does not come back here
+	if err := s.commitBatch(ctx, committer, evictions); err != nil {
+		return err
+	}
+	for _, key := range victims {
+		if s.readByAny(ctx, key, snapshots) {
+			s.data[key].RetainVersions(ctx, snapshots)
+			s.recountKey(ctx, key)
+		} else {
+			s.dropKey(key)
+		}
+	}
+	s.memory.evictions += len(victims)
+	s.logger.Infof("Evicted %d keys to stay under %d bytes", len(victims), s.memory.limit)
+	return nil
+}
+
+// openSnapshots returns the snapshot txIDs of the open transactions but
+// committer and of the pinned read-only snapshots. Callers must hold the write
+// lock.
+func (s *memStore) openSnapshots(committer int) []int {
+	snapshots := make([]int, 0, len(s.affectedKeysInTransaction))
+	for txID := range s.affectedKeysInTransaction {
+		if txID != committer {
+			snapshots = append(snapshots, txID)
+		}
+	}
+
+	s.pinMutex.Lock()
+	defer s.pinMutex.Unlock()
+	for txID := range s.pins {
+		snapshots = append(snapshots, txID)
+	}
+	return snapshots
+}
+
+// evictionGain is how many bytes evicting key frees right away: all of it if
+// no snapshot reads a version of it, else the versions none of them reads
+// minus the tombstone. Deleted keys and keys locked by another transaction
+// gain nothing. Callers must hold the write lock.
+func (s *memStore) evictionGain(ctx context.Context, committer int, key string, snapshots []int) int64 {
+	if s.data[key].GetCommitted(ctx) == nil {
+		return 0
+	}
+	if _, _, _, locked := s.locks.lockedByOther(committer, []string{key}); locked {
+		return 0
+	}
+	if !s.readByAny(ctx, key, snapshots) {
+		return s.memory.keys[key].size
+	}
+
+	history := s.data[key].History(ctx)
+	gain := -int64(versionOverhead)
+	for i, read := range version.ReadBySnapshots(history, snapshots) {
+		if !read {
+			gain += versionOverhead + sizeOf(history[i].Value)
+		}
+	}
+	return gain
+}
+
+// readByAny reports whether one of snapshots reads a version of key.
+func (s *memStore) readByAny(ctx context.Context, key string, snapshots []int) bool {
+	for _, read := range version.ReadBySnapshots(s.data[key].History(ctx), snapshots) {
+		if read {
+			return true
+		}
+	}
+	return false
+}
+
+// pickEvictionVictim samples a few keys not in skip and returns the best one
+// to evict under the store's policy.
+func (s *memStore) pickEvictionVictim(skip map[string]bool) (string, bool) {
+	victim, found := "", false
+	var victimScore [2]uint64
+	sampled := 0
+	consider := func(key string, score [2]uint64) bool {
+		if skip[key] {
+			return true
+		}
+		if !found || score[0] < victimScore[0] || (score[0] == victimScore[0] && score[1] < victimScore[1]) {
+			victim, victimScore, found = key, score, true
+		}
+		sampled++
+		return sampled < evictionSamples
+	}
+
+	// map iteration starts at a random key, which is what the sampling needs
+	if s.memory.policy == EvictNearestTTL {
+		for key, expireAt := range s.expiring {
+			if !consider(key, [2]uint64{uint64(expireAt.UnixNano()), 0}) {
+				break
+			}
+		}
+		return victim, found
+	}
+
+	for key, usage := range s.memory.keys {
+		var score [2]uint64
+		switch s.memory.policy {
+		case EvictLRU:
+			score = [2]uint64{usage.lastUsed.Load(), 0}
+		case EvictLFU:
+			score = [2]uint64{usage.hits.Load(), usage.lastUsed.Load()}
+		}
+		if !consider(key, score) {
+			break
+		}
+	}
+	return victim, found
+}
+
+// sizeOf estimates the bytes value holds.
+func sizeOf(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v)) + 16
+	case []byte:
+		return int64(len(v)) + 24
+	case map[string]interface{}:
+		size := int64(48)
+		for key, item := range v {
+			size += int64(len(key)) + 32 + sizeOf(item)
+		}
+		return size
+	case []interface{}:
+		size := int64(24)
+		for _, item := range v {
+			size += 16 + sizeOf(item)
+		}
+		return size
+	default:
+		return int64(reflect.TypeOf(value).Size())
+	}
+}
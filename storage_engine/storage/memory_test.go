@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+// keyCost is what one key holding a single int costs in the accounting.
+func keyCost(key string) int64 {
+	return int64(len(key)) + keyOverhead + versionOverhead + sizeOf(0)
+}
+
+func TestMemStorage_MemoryLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("No eviction rejects writes over the limit", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(3*keyCost("k0"), NoEviction))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, storage.Set(ctx, fmt.Sprintf("k%d", i), i))
+		}
+		assert.Equal(t, appCommon.OutOfMemory, storage.Set(ctx, "k3", 3))
+
+		// deletes are always allowed and free the key once collected
+		assert.NoError(t, storage.Delete(ctx, "k0"))
+		assert.NoError(t, storage.Set(ctx, "k3", 3))
+
+		usage := storage.MemoryUsage(ctx)
+		assert.LessOrEqual(t, usage.Used, usage.Limit)
+		assert.Equal(t, 0, usage.Evictions)
+	})
+
+	t.Run("Old versions are collected before evicting", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(2*keyCost("k0"), NoEviction))
+		assert.NoError(t, storage.Set(ctx, "k0", 0))
+		assert.NoError(t, storage.Set(ctx, "k0", 1))
+		assert.NoError(t, storage.Set(ctx, "k1", 1))
+
+		history, _ := storage.History(ctx, "k0")
+		assert.Len(t, history, 1)
+		assert.Equal(t, 0, storage.MemoryUsage(ctx).Evictions)
+	})
+
+	t.Run("LRU evicts the least recently used key", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(3*keyCost("k0"), EvictLRU))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, storage.Set(ctx, fmt.Sprintf("k%d", i), i))
+		}
+		_, _ = storage.Get(ctx, "k0")
+		_, _ = storage.Get(ctx, "k2")
+
+		assert.NoError(t, storage.Set(ctx, "k3", 3))
+
+		keys, _ := storage.Keys(ctx, "*")
+		assert.Equal(t, []string{"k0", "k2", "k3"}, keys)
+		assert.Equal(t, 1, storage.MemoryUsage(ctx).Evictions)
+	})
+
+	t.Run("LFU evicts the least frequently used key", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(3*keyCost("k0"), EvictLFU))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, storage.Set(ctx, fmt.Sprintf("k%d", i), i))
+		}
+		for i := 0; i < 3; i++ {
+			_, _ = storage.Get(ctx, "k0")
+			_, _ = storage.Get(ctx, "k1")
+		}
+		_, _ = storage.Get(ctx, "k2")
+
+		assert.NoError(t, storage.Set(ctx, "k3", 3))
+
+		keys, _ := storage.Keys(ctx, "*")
+		assert.Equal(t, []string{"k0", "k1", "k3"}, keys)
+	})
+
+	t.Run("Nearest TTL only evicts expiring keys", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(3*keyCost("k0"), EvictNearestTTL))
+		assert.NoError(t, storage.Set(ctx, "k0", 0))
+		assert.NoError(t, storage.SetWithTTL(ctx, "k1", 1, time.Hour))
+		assert.NoError(t, storage.SetWithTTL(ctx, "k2", 2, time.Minute))
+
+		assert.NoError(t, storage.Set(ctx, "k3", 3))
+		keys, _ := storage.Keys(ctx, "*")
+		assert.Equal(t, []string{"k0", "k1", "k3"}, keys)
+	})
+
+	t.Run("Keys pinned by an open transaction are never evicted", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(2*keyCost("k0"), EvictRandom))
+		assert.NoError(t, storage.Set(ctx, "k0", 0))
+		assert.NoError(t, storage.Set(ctx, "k1", 1))
+
+		tx := storage.Tx()
+		assert.Equal(t, appCommon.OutOfMemory, storage.Set(ctx, "k2", 2))
+
+		value, err := tx.Get(ctx, "k0")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, value)
+		assert.NoError(t, tx.Abort(ctx))
+
+		assert.NoError(t, storage.Set(ctx, "k2", 2))
+		assert.Equal(t, 1, storage.MemoryUsage(ctx).Evictions)
+	})
+
+	t.Run("Keys no open transaction reads are still evicted", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(3*keyCost("k0"), EvictLRU))
+		assert.NoError(t, storage.Set(ctx, "k0", 0))
+		tx := storage.Tx()
+		readTx := storage.ReadTx()
+		assert.NoError(t, storage.Set(ctx, "k1", 1))
+		assert.NoError(t, storage.Set(ctx, "k2", 2))
+
+		// k0 is the least recently used key but both transactions read it
+		assert.NoError(t, storage.Set(ctx, "k3", 3))
+		keys, _ := storage.Keys(ctx, "*")
+		assert.Equal(t, []string{"k0", "k2", "k3"}, keys)
+		assert.Equal(t, 1, storage.MemoryUsage(ctx).Evictions)
+
+		value, err := tx.Get(ctx, "k0")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, value)
+		value, err = readTx.Get(ctx, "k0")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, value)
+		assert.NoError(t, tx.Abort(ctx))
+		assert.NoError(t, readTx.Abort(ctx))
+	})
+
+	t.Run("Committing transaction can evict", func(t *testing.T) {
+		storage := NewMemStore(WithMaxMemory(2*keyCost("k0"), EvictRandom))
+		assert.NoError(t, storage.Set(ctx, "k0", 0))
+		assert.NoError(t, storage.Set(ctx, "k1", 1))
+
+		tx := storage.Tx()
+		assert.NoError(t, tx.Set(ctx, "k2", 2))
+		assert.NoError(t, tx.Commit(ctx))
+
+		value, _ := storage.Get(ctx, "k2")
+		assert.Equal(t, 2, value)
+	})
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/storage_engine/version"
+)
+
+func TestMemStorage_MultipleStoresDoNotShareTxIDs(t *testing.T) {
+	ctx := context.Background()
+	storeA := NewMemStore()
+	storeB := NewMemStore()
+
+	txA := storeA.Tx()
+	assert.NoError(t, txA.Set(ctx, "key1", "valueA"))
+
+	// writes on another store must not bump the clock of storeA
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, storeB.Set(ctx, "key1", i))
+	}
+
+	assert.NoError(t, txA.Commit(ctx))
+
+	valueA, err := storeA.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "valueA", valueA)
+
+	valueB, err := storeB.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, 9, valueB)
+}
+
+func TestMemStorage_WithClock(t *testing.T) {
+	ctx := context.Background()
+	clock := version.NewClock(100)
+	storage := NewMemStore(WithClock(clock))
+
+	assert.NoError(t, storage.Set(ctx, "key1", "value1"))
+	assert.Equal(t, 101, clock.Current())
+
+	tx := storage.Tx()
+	assert.NoError(t, tx.Set(ctx, "key1", "value2"))
+	assert.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, 103, clock.Current())
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/storage_engine/operation"
+	"sync"
+)
+
+// Begin starts a child transaction. It reads the snapshot of tx with the
+// pending writes of tx (and of its own parents) on top, and keeps its own
+// writes apart until it commits them into tx. Only the outermost transaction
+// commits to the store and is checked for conflicts, so a child cannot fail
+// with TxConflictError. The child shares the deadline, isolation level and
+// read set of the outermost transaction, and ends with it.
+func (tx *memTx) Begin() MemTx {
+	tx.memStore.logger.Infof("Child transaction of %d starts", tx.txID)
+	return &memTx{
+		memStore:   tx.memStore,
+		txID:       tx.txID,
+		startedAt:  tx.startedAt,
+		rwLock:     new(sync.RWMutex),
+		isolation:  tx.isolation,
+		parent:     tx,
+		operations: operation.NewOperationsKeyStore(),
+	}
+}
+
+// Begin starts another read-only transaction on the same snapshot and with the
+// same deadline, it has to be finished on its own.
+func (tx *readTx) Begin() MemTx {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	return tx.memStore.openReadTx(tx.snapshot, tx.startedAt, tx.deadline, tx.ctx)
+}
+
+func (tx *memTx) root() *memTx {
+	for tx.parent != nil {
+		tx = tx.parent
+	}
+	return tx
+}
+
+// keyStore holds the pending writes of tx alone. Callers must hold at least the
+// store read lock.
+func (tx *memTx) keyStore() operation.KeyStore {
+	if tx.operations != nil {
+		return tx.operations
+	}
+	return tx.memStore.affectedKeysInTransaction[tx.txID]
+}
+
+// pendingOperation returns the pending write of key seen by tx, its own or
+// else the one of the closest parent having one.
+func (tx *memTx) pendingOperation(key string) (operation.Operation, bool) {
+	for ; tx != nil; tx = tx.parent {
+		if op, exist := tx.keyStore().GetOperation(key); exist {
+			return op, true
+		}
+	}
+	return operation.Operation{}, false
+}
+
+// pendingOperations returns a copy of every pending write seen by tx.
+func (tx *memTx) pendingOperations() map[string]operation.Operation {
+	if tx.parent == nil {
+		return tx.keyStore().CopyOperations()
+	}
+	operations := tx.parent.pendingOperations()
+	for key, op := range tx.keyStore().CopyOperations() {
+		operations[key] = op
+	}
+	return operations
+}
+
+// commitChild hands the writes of a child transaction to its parent, where
+// they can still be rolled back to a savepoint of the parent.
+func (tx *memTx) commitChild(ctx context.Context) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+	parentStore := tx.parent.keyStore()
+	for key, op := range tx.keyStore().CopyOperations() {
+		parentStore.Put(key, op)
+	}
+	tx.done.Store(true)
+	tx.memStore.logger.Infof("Child transaction of %d is merged", tx.txID)
+	return nil
+}
+
+// abortChild discards the writes of a child transaction, like Abort it
+// ignores whether ctx is done.
+func (tx *memTx) abortChild(ctx context.Context) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	err := tx.checkOpen(context.WithoutCancel(ctx))
+	tx.done.Store(true)
+	return err
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemTx_Begin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("A child sees the parent and merges into it", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, storage.Set(ctx, "b", 1))
+
+		parent := storage.Tx()
+		assert.NoError(t, parent.Set(ctx, "a", 2))
+		assert.NoError(t, storage.Set(ctx, "c", 1)) // after the snapshot
+
+		child := parent.Begin()
+		value, _ := child.Get(ctx, "a")
+		assert.Equal(t, 2, value)
+		value, _ = child.Get(ctx, "c")
+		assert.Nil(t, value)
+
+		assert.NoError(t, child.Set(ctx, "a", 3))
+		assert.NoError(t, child.Delete(ctx, "b"))
+		value, _ = parent.Get(ctx, "a")
+		assert.Equal(t, 2, value)
+
+		result, err := child.Scan(ctx, "", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "a", Value: 3}}, result)
+
+		assert.NoError(t, child.Commit(ctx))
+		assert.Error(t, child.Set(ctx, "a", 4))
+		value, _ = parent.Get(ctx, "a")
+		assert.Equal(t, 3, value)
+		value, _ = parent.Get(ctx, "b")
+		assert.Nil(t, value)
+
+		assert.NoError(t, parent.Commit(ctx))
+		value, _ = storage.Get(ctx, "a")
+		assert.Equal(t, 3, value)
+		value, _ = storage.Get(ctx, "b")
+		assert.Nil(t, value)
+	})
+
+	t.Run("An aborted child leaves the parent as it was", func(t *testing.T) {
+		storage := NewMemStore()
+		parent := storage.Tx()
+		assert.NoError(t, parent.Set(ctx, "a", 1))
+
+		child := parent.Begin()
+		grandchild := child.Begin()
+		assert.NoError(t, grandchild.Set(ctx, "a", 2))
+		assert.NoError(t, grandchild.Commit(ctx))
+		value, _ := child.Get(ctx, "a")
+		assert.Equal(t, 2, value)
+
+		assert.NoError(t, child.Abort(ctx))
+		value, _ = parent.Get(ctx, "a")
+		assert.Equal(t, 1, value)
+		assert.NoError(t, parent.Commit(ctx))
+	})
+
+	t.Run("Only the outermost commit checks for conflicts", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+
+		parent := storage.Tx()
+		child := parent.Begin()
+		assert.NoError(t, child.Set(ctx, "a", 2))
+		assert.NoError(t, storage.Set(ctx, "a", 10))
+
+		assert.NoError(t, child.Commit(ctx))
+		var conflict *appCommon.TxConflictError
+		assert.ErrorAs(t, parent.Commit(ctx), &conflict)
+		assert.NoError(t, parent.Abort(ctx))
+	})
+
+	t.Run("A child ends with its parent", func(t *testing.T) {
+		storage := NewMemStore()
+		parent := storage.Tx()
+		child := parent.Begin()
+		assert.NoError(t, parent.Abort(ctx))
+
+		assert.Error(t, child.Set(ctx, "a", 1))
+		assert.Error(t, child.Commit(ctx))
+	})
+}
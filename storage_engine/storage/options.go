@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"in-memory-storage-engine/storage_engine/version"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+type Option func(s *memStore)
+
+// WithClock makes the store use the given version clock instead of a fresh one
+// starting at zero.
+func WithClock(clock version.Clock) Option {
+	return func(s *memStore) {
+		s.clock = clock
+	}
+}
+
+// WithMaxMemory caps the estimated memory of all keys and versions at maxBytes.
+// Writes that would go over it first reclaim old versions, then evict keys
+// following policy, and fail with appCommon.OutOfMemory if that is not enough.
+func WithMaxMemory(maxBytes int64, policy EvictionPolicy) Option {
+	return func(s *memStore) {
+		s.memory.limit = maxBytes
+		s.memory.policy = policy
+	}
+}
+
+// WithChangelogSize sets how many commits are kept for subscribers that fall
+// behind, DefaultChangelogSize by default.
+func WithChangelogSize(size int) Option {
+	return func(s *memStore) {
+		s.changelogSize = size
+	}
+}
+
+// WithLockWait sets what writers do about keys locked by a transaction, Block
+// by default. Transactions can override it with WithTxLockWait.
+func WithLockWait(policy LockWaitPolicy) Option {
+	return func(s *memStore) {
+		s.lockWait = policy
+	}
+}
+
+// WithStarvationThreshold sets after how many conflict aborts in a row the
+// transactions of an identity reserve their keys, DefaultStarvationThreshold by
+// default. A threshold <= 0 never reserves.
+func WithStarvationThreshold(aborts int) Option {
+	return func(s *memStore) {
+		s.starvationThreshold = aborts
+	}
+}
+
+// WithWAL makes every commit durable in an append-only log inside dir. The log
+// is replayed when the store is created.
+func WithWAL(dir string, options wal.Options) Option {
+	return func(s *memStore) {
+		s.walDir = dir
+		s.walOptions = options
+	}
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/btree"
+	"sync/atomic"
+	"time"
+)
+
+// readTx is a read-only view pinned at a snapshot txID. It is not registered
+// in affectedKeysInTransaction, never conflicts and finishes without taking
+// the store write lock. Like other transactions it expires at its deadline or
+// when its context is done, and is then unpinned by the reaper.
+type readTx struct {
+	memStore  *memStore
+	snapshot  int
+	startedAt time.Time
+	deadline  time.Time
+	ctx       context.Context
+	stop      func() bool
+	done      atomic.Bool
+}
+
+// ReadTx starts a read-only transaction seeing everything committed so far.
+func (s *memStore) ReadTx() MemTx {
+	return s.startReadTx(newTxOptions(nil))
+}
+
+func (s *memStore) startReadTx(options txOptions) *readTx {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	// commits take the write lock, so every txID up to Current is fully applied
+	snapshot := s.clock.Current()
+	startedAt := time.Now()
+	s.logger.Infof("Read-only transaction at %d starts", snapshot)
+	return s.openReadTx(snapshot, startedAt, options.deadline(startedAt), options.ctx)
+}
+
+// openReadTx pins snapshot for a new read-only transaction. Callers must hold
+// at least the read lock.
+func (s *memStore) openReadTx(snapshot int, startedAt, deadline time.Time, ctx context.Context) *readTx {
+	tx := &readTx{memStore: s, snapshot: snapshot, startedAt: startedAt, deadline: deadline, ctx: ctx}
+
+	s.pinMutex.Lock()
+	defer s.pinMutex.Unlock()
+	s.pins[snapshot]++
+	s.readTxs[tx] = struct{}{}
+	// release reads stop under pinMutex, so it cannot run before it is set
+	if ctx != nil {
+		tx.stop = context.AfterFunc(ctx, func() {
+			if tx.release() {
+				s.logger.Infof("Context of read-only transaction at %d is done, releasing it", snapshot)
+			}
+		})
+	}
+	return tx
+}
+
+// reapExpiredReadTxs releases the read-only transactions past their deadline,
+// so they no longer hold back the watermark.
+func (s *memStore) reapExpiredReadTxs(now time.Time) {
+	s.pinMutex.Lock()
+	expired := make([]*readTx, 0)
+	for tx := range s.readTxs {
+		if tx.expired(now) {
+			expired = append(expired, tx)
+		}
+	}
+	s.pinMutex.Unlock()
+
+	for _, tx := range expired {
+		if tx.release() {
+			s.logger.Infof("Read-only transaction at %d expired at %v, releasing it", tx.snapshot, tx.deadline)
+		}
+	}
+}
+
+// ID returns the snapshot txID. It may be shared with other transactions and
+// is not a handle for AbortTransaction.
+func (tx *readTx) ID() int {
+	return tx.snapshot
+}
+
+func (tx *readTx) Set(ctx context.Context, key string, value interface{}) error {
+	return tx.rejectWrite(ctx)
+}
+
+func (tx *readTx) Delete(ctx context.Context, key string) error {
+	return tx.rejectWrite(ctx)
+}
+
+func (tx *readTx) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return tx.rejectWrite(ctx)
+}
+
+func (tx *readTx) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return tx.rejectWrite(ctx)
+}
+
+func (tx *readTx) Persist(ctx context.Context, key string) error {
+	return tx.rejectWrite(ctx)
+}
+
+func (tx *readTx) TTL(ctx context.Context, key string) (time.Duration, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return 0, err
+	}
+
+	if !tx.memStore.checkKeyExist(key) {
+		return 0, appCommon.KeyDoesNotExist
+	}
+	info, ok := tx.memStore.data[key].GetVersionBeforeTransaction(ctx, tx.snapshot, tx.startedAt)
+	if !ok {
+		return 0, appCommon.KeyDoesNotExist
+	}
+	return remainingTTL(info.ExpireAt), nil
+}
+
+func (tx *readTx) Get(ctx context.Context, key string) (interface{}, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	if tx.memStore.checkKeyExist(key) {
+		tx.memStore.touchKey(key)
+		return tx.memStore.data[key].GetValueBeforeTransaction(ctx, tx.snapshot, tx.startedAt), nil
+	}
+	return nil, nil
+}
+
+func (tx *readTx) Scan(ctx context.Context, start, end string, limit int) ([]KeyValue, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	return tx.memStore.mergedScan(ctx, tx.snapshot, tx.startedAt, nil, start, end, limit), nil
+}
+
+func (tx *readTx) ScanPrefix(ctx context.Context, prefix string) ([]KeyValue, error) {
+	return tx.Scan(ctx, prefix, btree.PrefixEnd(prefix), 0)
+}
+
+// Watch is a no-op: a read-only transaction has nothing to validate.
+func (tx *readTx) Watch(ctx context.Context, keys ...string) error {
+	return tx.checkOpen(ctx)
+}
+
+func (tx *readTx) Commit(ctx context.Context) error {
+	return tx.finish(ctx)
+}
+
+func (tx *readTx) Abort(ctx context.Context) error {
+	return tx.finish(ctx)
+}
+
+func (tx *readTx) finish(ctx context.Context) error {
+	released := tx.release()
+	if tx.expired(time.Now()) {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxExpiredError(tx.snapshot))
+		return appCommon.NewTxExpiredError(tx.snapshot)
+	}
+	if !released {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.snapshot))
+		return appCommon.NewTxIDDoesNotExistError(tx.snapshot)
+	}
+	tx.memStore.logger.Infof("Read-only transaction at %d finished", tx.snapshot)
+	return nil
+}
+
+// release unpins the snapshot, it reports false if that was done already.
+func (tx *readTx) release() bool {
+	if !tx.done.CompareAndSwap(false, true) {
+		return false
+	}
+	tx.memStore.pinMutex.Lock()
+	delete(tx.memStore.readTxs, tx)
+	stop := tx.stop
+	tx.memStore.pinMutex.Unlock()
+
+	tx.memStore.unpin(tx.snapshot)
+	if stop != nil {
+		stop()
+	}
+	return true
+}
+
+// expired reports whether the transaction is past its deadline or its context
+// is done.
+func (tx *readTx) expired(now time.Time) bool {
+	if !tx.deadline.IsZero() && !now.Before(tx.deadline) {
+		return true
+	}
+	return tx.ctx != nil && tx.ctx.Err() != nil
+}
+
+// checkOpen must be called while holding at least the read lock before reading
+// at the snapshot, so an expired transaction is not collected in between.
+func (tx *readTx) checkOpen(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	if tx.expired(time.Now()) {
+		tx.release()
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxExpiredError(tx.snapshot))
+		return appCommon.NewTxExpiredError(tx.snapshot)
+	}
+	if tx.done.Load() {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.snapshot))
+		return appCommon.NewTxIDDoesNotExistError(tx.snapshot)
+	}
+	return nil
+}
+
+func (tx *readTx) rejectWrite(ctx context.Context) error {
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+	tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewReadOnlyTxError(tx.snapshot))
+	return appCommon.NewReadOnlyTxError(tx.snapshot)
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemStorage_ReadTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Read-only transaction keeps its snapshot", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+
+		tx := storage.ReadTx()
+		assert.NoError(t, storage.Set(ctx, "a", 2))
+		assert.NoError(t, storage.Set(ctx, "b", 3))
+
+		value, err := tx.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, value)
+
+		values, err := tx.Scan(ctx, "", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []KeyValue{{Key: "a", Value: 1}}, values)
+
+		assert.NoError(t, tx.Commit(ctx))
+		assert.Error(t, tx.Commit(ctx))
+		_, err = tx.Get(ctx, "a")
+		assert.Error(t, err)
+	})
+
+	t.Run("Read-only transaction rejects writes", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.Tx(ReadOnly())
+
+		var readOnly *appCommon.ReadOnlyTxError
+		assert.ErrorAs(t, tx.Set(ctx, "a", 1), &readOnly)
+		assert.ErrorAs(t, tx.Delete(ctx, "a"), &readOnly)
+		assert.NoError(t, tx.Abort(ctx))
+	})
+
+	t.Run("Read-only transaction is not tracked", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.ReadTx()
+
+		assert.Empty(t, storage.Transactions(ctx))
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, tx.Commit(ctx))
+	})
+}
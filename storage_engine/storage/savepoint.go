@@ -0,0 +1,58 @@
+package storage
+
+import "context"
+
+func (tx *memTx) Savepoint(ctx context.Context, name string) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+	tx.keyStore().Savepoint(name)
+	tx.memStore.logger.Infof("Savepoint %s in transaction %d", name, tx.txID)
+	return nil
+}
+
+func (tx *memTx) RollbackTo(ctx context.Context, name string) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+	if err := tx.keyStore().RollbackTo(name); err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	tx.memStore.logger.Infof("Transaction %d rolled back to savepoint %s", tx.txID, name)
+	return nil
+}
+
+func (tx *memTx) Release(ctx context.Context, name string) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+	if err := tx.keyStore().Release(name); err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	return nil
+}
+
+// Savepoint, RollbackTo and Release are no-ops: a read-only transaction has
+// no writes to undo.
+func (tx *readTx) Savepoint(ctx context.Context, name string) error {
+	return tx.checkOpen(ctx)
+}
+
+func (tx *readTx) RollbackTo(ctx context.Context, name string) error {
+	return tx.checkOpen(ctx)
+}
+
+func (tx *readTx) Release(ctx context.Context, name string) error {
+	return tx.checkOpen(ctx)
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemTx_Savepoints(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rolling back undoes the later writes only", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, storage.Set(ctx, "b", 1))
+
+		tx := storage.Tx()
+		assert.NoError(t, tx.Set(ctx, "a", 2))
+		assert.NoError(t, tx.Savepoint(ctx, "batch"))
+		assert.NoError(t, tx.Delete(ctx, "a"))
+		assert.NoError(t, tx.Delete(ctx, "b"))
+		assert.NoError(t, tx.Set(ctx, "c", 3))
+
+		// committed meanwhile, the snapshot must not see it after the rollback
+		assert.NoError(t, storage.Set(ctx, "b", 10))
+
+		assert.NoError(t, tx.RollbackTo(ctx, "batch"))
+		value, _ := tx.Get(ctx, "a")
+		assert.Equal(t, 2, value)
+		value, _ = tx.Get(ctx, "b")
+		assert.Equal(t, 1, value)
+		value, _ = tx.Get(ctx, "c")
+		assert.Nil(t, value)
+
+		assert.NoError(t, tx.Release(ctx, "batch"))
+		assert.Error(t, tx.RollbackTo(ctx, "batch"))
+		assert.NoError(t, tx.Commit(ctx))
+
+		value, _ = storage.Get(ctx, "a")
+		assert.Equal(t, 2, value)
+		value, _ = storage.Get(ctx, "b")
+		assert.Equal(t, 10, value)
+		_, err := storage.Get(ctx, "c")
+		assert.ErrorIs(t, err, appCommon.KeyDoesNotExist)
+	})
+
+	t.Run("Savepoints on a finished transaction fail", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.Tx()
+		assert.NoError(t, tx.Abort(ctx))
+		assert.Error(t, tx.Savepoint(ctx, "sp"))
+	})
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/storage_engine/btree"
+	"in-memory-storage-engine/storage_engine/operation"
+	"sort"
+	"time"
+)
+
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+func (s *memStore) Scan(ctx context.Context, start, end string, limit int) ([]KeyValue, error) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	result := make([]KeyValue, 0)
+	s.keys.Ascend(start, end, func(key string, _ struct{}) bool {
+		if value := s.data[key].GetCommitted(ctx); value != nil {
+			result = append(result, KeyValue{Key: key, Value: value})
+		}
+		return limit <= 0 || len(result) < limit
+	})
+	return result, nil
+}
+
+func (s *memStore) ScanPrefix(ctx context.Context, prefix string) ([]KeyValue, error) {
+	return s.Scan(ctx, prefix, btree.PrefixEnd(prefix), 0)
+}
+
+func (tx *memTx) Scan(ctx context.Context, start, end string, limit int) ([]KeyValue, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	pending := tx.pendingOperations()
+	result := tx.memStore.mergedScan(ctx, tx.txID, tx.startedAt, pending, start, end, limit)
+
+	// a scan stopped by its limit has only read up to its last key
+	readEnd := end
+	if limit > 0 && len(result) == limit {
+		readEnd = result[len(result)-1].Key + "\x00"
+	}
+	tx.trackReadRange(start, readEnd)
+	return result, nil
+}
+
+func (tx *memTx) ScanPrefix(ctx context.Context, prefix string) ([]KeyValue, error) {
+	return tx.Scan(ctx, prefix, btree.PrefixEnd(prefix), 0)
+}
+
+// mergedScan walks the committed keys of [start, end) at the snapshot of txID
+// taken at time at, and the pending operations of a transaction side by side,
+// the pending ones winning on equal keys. Callers must hold at least the read
+// lock.
+func (s *memStore) mergedScan(ctx context.Context, txID int, at time.Time, pending map[string]operation.Operation, start, end string, limit int) []KeyValue {
+	pendingKeys := make([]string, 0, len(pending))
+	for key := range pending {
+		if key >= start && (end == "" || key < end) {
+			pendingKeys = append(pendingKeys, key)
+		}
+	}
+	sort.Strings(pendingKeys)
+
+	result := make([]KeyValue, 0)
+	full := func() bool {
+		return limit > 0 && len(result) >= limit
+	}
+	emitPending := func(key string) {
+		if value, ok := pendingValue(pending[key], time.Now()); ok {
+			result = append(result, KeyValue{Key: key, Value: value})
+		}
+	}
+
+	next := 0
+	s.keys.Ascend(start, end, func(key string, _ struct{}) bool {
+		for next < len(pendingKeys) && pendingKeys[next] < key && !full() {
+			emitPending(pendingKeys[next])
+			next++
+		}
+		if full() {
+			return false
+		}
+
+		if next < len(pendingKeys) && pendingKeys[next] == key {
+			emitPending(key)
+			next++
+		} else if value := s.data[key].GetValueBeforeTransaction(ctx, txID, at); value != nil {
+			result = append(result, KeyValue{Key: key, Value: value})
+		}
+		return !full()
+	})
+
+	for ; next < len(pendingKeys) && !full(); next++ {
+		emitPending(pendingKeys[next])
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanKeys(values []KeyValue) []string {
+	keys := make([]string, len(values))
+	for i, value := range values {
+		keys[i] = value.Key
+	}
+	return keys
+}
+
+func TestMemStorage_Scan(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemStore()
+
+	for _, key := range []string{"user:3", "order:1", "user:1", "user:2", "user:10", "userx"} {
+		assert.NoError(t, storage.Set(ctx, key, key+"-value"))
+	}
+	assert.NoError(t, storage.Delete(ctx, "user:2"))
+
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		limit  int
+		expect []string
+	}{
+		{"all keys", "", "", 0, []string{"order:1", "user:1", "user:10", "user:3", "userx"}},
+		{"bounded range", "user:1", "user:3", 0, []string{"user:1", "user:10"}},
+		{"limit", "user:", "", 2, []string{"user:1", "user:10"}},
+		{"empty range", "z", "", 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := storage.Scan(ctx, tt.start, tt.end, tt.limit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, scanKeys(values))
+		})
+	}
+
+	values, err := storage.ScanPrefix(ctx, "user:")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:1", "user:10", "user:3"}, scanKeys(values))
+	assert.Equal(t, "user:1-value", values[0].Value)
+}
+
+func TestMemStorage_TransactionScan(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemStore()
+
+	assert.NoError(t, storage.Set(ctx, "key:1", "committed1"))
+	assert.NoError(t, storage.Set(ctx, "key:3", "committed3"))
+	assert.NoError(t, storage.Set(ctx, "key:5", "committed5"))
+
+	tx := storage.Tx()
+	assert.NoError(t, tx.Set(ctx, "key:2", "pending2"))
+	assert.NoError(t, tx.Set(ctx, "key:3", "pending3"))
+	assert.NoError(t, tx.Delete(ctx, "key:5"))
+	assert.NoError(t, tx.Set(ctx, "other", "ignored"))
+
+	// committed after the transaction started, must not be visible to it
+	assert.NoError(t, storage.Set(ctx, "key:4", "tooLate"))
+
+	values, err := tx.ScanPrefix(ctx, "key:")
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyValue{
+		{Key: "key:1", Value: "committed1"},
+		{Key: "key:2", Value: "pending2"},
+		{Key: "key:3", Value: "pending3"},
+	}, values)
+
+	values, err = tx.Scan(ctx, "key:2", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key:2", "key:3"}, scanKeys(values))
+
+	values, err = tx.Scan(ctx, "key:6", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other"}, scanKeys(values))
+
+	assert.NoError(t, tx.Abort(ctx))
+	_, err = tx.Scan(ctx, "", "", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+// onCallShift is the classic write skew: at least one doctor must stay on
+// call, each transaction checks that the other one is and then leaves.
+func onCallShift(t *testing.T, storage MemStorage, opts ...TxOption) (error, error) {
+	ctx := context.Background()
+	assert.NoError(t, storage.Set(ctx, "alice", "on call"))
+	assert.NoError(t, storage.Set(ctx, "bob", "on call"))
+
+	tx1 := storage.Tx(opts...)
+	tx2 := storage.Tx(opts...)
+
+	bob, _ := tx1.Get(ctx, "bob")
+	assert.Equal(t, "on call", bob)
+	assert.NoError(t, tx1.Set(ctx, "alice", "off"))
+
+	alice, _ := tx2.Get(ctx, "alice")
+	assert.Equal(t, "on call", alice)
+	assert.NoError(t, tx2.Set(ctx, "bob", "off"))
+
+	return tx1.Commit(ctx), tx2.Commit(ctx)
+}
+
+func TestMemStorage_WriteSkew(t *testing.T) {
+	t.Run("Repeatable read allows write skew", func(t *testing.T) {
+		err1, err2 := onCallShift(t, NewMemStore())
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+	})
+
+	t.Run("Serializable rejects write skew", func(t *testing.T) {
+		err1, err2 := onCallShift(t, NewMemStore(), WithIsolation(Serializable))
+		assert.NoError(t, err1)
+
+		var conflict *appCommon.TxConflictError
+		assert.ErrorAs(t, err2, &conflict)
+	})
+}
+
+func TestMemStorage_SerializableScan(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Phantom insert into a scanned range", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "task:1", "open"))
+
+		tx := storage.Tx(WithIsolation(Serializable))
+		tasks, err := tx.ScanPrefix(ctx, "task:")
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+		assert.NoError(t, tx.Set(ctx, "summary", len(tasks)))
+
+		assert.NoError(t, storage.Set(ctx, "task:2", "open"))
+
+		var conflict *appCommon.TxConflictError
+		assert.ErrorAs(t, tx.Commit(ctx), &conflict)
+	})
+
+	t.Run("Write outside the scanned range", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "task:1", "open"))
+
+		tx := storage.Tx(WithIsolation(Serializable))
+		_, err := tx.ScanPrefix(ctx, "task:")
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Set(ctx, "summary", 1))
+
+		assert.NoError(t, storage.Set(ctx, "user:1", "unrelated"))
+		assert.NoError(t, tx.Commit(ctx))
+	})
+
+	t.Run("Limited scan only reads up to its last key", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		assert.NoError(t, storage.Set(ctx, "b", 2))
+
+		tx := storage.Tx(WithIsolation(Serializable))
+		values, err := tx.Scan(ctx, "", "", 1)
+		assert.NoError(t, err)
+		assert.Len(t, values, 1)
+		assert.NoError(t, tx.Set(ctx, "result", values[0].Value))
+
+		assert.NoError(t, storage.Set(ctx, "b", 3))
+		assert.NoError(t, tx.Commit(ctx))
+	})
+}
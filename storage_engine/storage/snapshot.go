@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"in-memory-storage-engine/storage_engine/version"
+	"in-memory-storage-engine/storage_engine/wal"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// snapshotHeader starts every snapshot file, it is followed by exactly Keys
+// snapshotEntry values.
+type snapshotHeader struct {
+	TxID int
+	Keys int
+}
+
+// snapshotEntry is the latest version of one key visible at snapshotHeader.TxID.
+type snapshotEntry struct {
+	Key      string
+	TxID     int
+	Value    interface{}
+	ExpireAt time.Time
+}
+
+type snapshotKey struct {
+	key     string
+	manager version.VersionManager
+}
+
+// Snapshot writes the committed state as of a single txID to path and returns
+// that txID. Writers are only blocked while the set of keys is collected, the
+// values themselves are read from the MVCC history afterward. Once the file is
+// safely in place the WAL (if any) is truncated up to the snapshot.
+func (s *memStore) Snapshot(ctx context.Context, path string) (int, error) {
+	s.rwMutex.RLock()
+	txID := s.clock.Current()
+	at := time.Now()
+	s.pin(txID)
+	defer s.unpin(txID)
+	keys := make([]snapshotKey, 0, len(s.data))
+	for key, manager := range s.data {
+		keys = append(keys, snapshotKey{key: key, manager: manager})
+	}
+	s.rwMutex.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key < keys[j].key })
+
+	entries := make([]snapshotEntry, 0, len(keys))
+	for _, key := range keys {
+		info, ok := key.manager.GetVersionBeforeTransaction(ctx, txID, at)
+		if !ok {
+			continue
+		}
+		entries = append(entries, snapshotEntry{Key: key.key, TxID: info.TxID, Value: info.Value, ExpireAt: info.ExpireAt})
+	}
+
+	if err := writeSnapshotFile(path, snapshotHeader{TxID: txID, Keys: len(entries)}, entries); err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return 0, err
+	}
+	s.logger.Infof("Snapshot of %d keys at transaction %d written to %s", len(entries), txID, path)
+
+	s.rwMutex.RLock()
+	log := s.wal
+	s.rwMutex.RUnlock()
+	if log != nil {
+		if err := log.TruncateBefore(txID); err != nil {
+			s.logger.WithContext(ctx).Errorln(err)
+			return txID, err
+		}
+	}
+	return txID, nil
+}
+
+func writeSnapshotFile(path string, header snapshotHeader, entries []snapshotEntry) error {
+	tmpPath := path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create snapshot directory: %w", err)
+	}
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot file: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := gob.NewEncoder(writer)
+	err = encoder.Encode(header)
+	for i := 0; err == nil && i < len(entries); i++ {
+		err = encoder.Encode(entries[i])
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot write snapshot at transaction %d: %w", header.TxID, err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("cannot move snapshot in place: %w", err)
+	}
+	return wal.SyncDir(filepath.Dir(path))
+}
+
+// LoadSnapshot creates a store from a snapshot written by Snapshot. When a WAL
+// is configured, records newer than the snapshot are replayed on top of it.
+func LoadSnapshot(path string, opts ...Option) (MemStorage, error) {
+	s := newMemStore(opts...)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open snapshot %s: %w", path, err)
+	}
+	defer file.Close()
+
+	ctx := context.Background()
+	decoder := gob.NewDecoder(bufio.NewReader(file))
+
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("cannot read snapshot header: %w", err)
+	}
+	for i := 0; i < header.Keys; i++ {
+		var entry snapshotEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("cannot read snapshot entry %d: %w", i, err)
+		}
+		s.setInternal(ctx, entry.Key, entry.Value, entry.TxID, entry.ExpireAt)
+	}
+	s.clock.Observe(header.TxID)
+	// the commits the snapshot folds in are not events a subscriber can get
+	s.changelog.startAfter(header.TxID)
+
+	if s.walDir != "" {
+		if err := s.openWAL(header.TxID); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func TestMemStorage_SnapshotAndLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.snapshot")
+	store := NewMemStore()
+
+	assert.NoError(t, store.Set(ctx, "key1", "value1"))
+	assert.NoError(t, store.Set(ctx, "key2", 2))
+	assert.NoError(t, store.Set(ctx, "key3", "deleted"))
+	assert.NoError(t, store.Delete(ctx, "key3"))
+
+	txID, err := store.Snapshot(ctx, path)
+	assert.NoError(t, err)
+
+	// writes after the snapshot must not be part of it
+	assert.NoError(t, store.Set(ctx, "key1", "afterSnapshot"))
+
+	loaded, err := LoadSnapshot(path)
+	assert.NoError(t, err)
+
+	value, err := loaded.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	value, err = loaded.Get(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	_, err = loaded.Get(ctx, "key3")
+	assert.Equal(t, appCommon.KeyDoesNotExist, err)
+
+	// the clock is restored so new transactions see the loaded data
+	tx := loaded.Tx()
+	value, err = tx.Get(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value)
+	assert.NoError(t, tx.Abort(ctx))
+
+	nextTxID, err := loaded.Snapshot(ctx, path)
+	assert.NoError(t, err)
+	assert.Greater(t, nextTxID, txID)
+}
+
+func TestMemStorage_SnapshotTruncatesWAL(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store.snapshot")
+	walDir := filepath.Join(dir, "wal")
+	options := wal.Options{SyncPolicy: wal.SyncEveryCommit}
+
+	store, err := OpenMemStore(WithWAL(walDir, options))
+	assert.NoError(t, err)
+	assert.NoError(t, store.Set(ctx, "key1", "value1"))
+	assert.NoError(t, store.Set(ctx, "key2", "value2"))
+
+	txID, err := store.Snapshot(ctx, path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Set(ctx, "key2", "afterSnapshot"))
+	assert.NoError(t, store.Delete(ctx, "key1"))
+	assert.NoError(t, store.Close())
+
+	log, err := wal.Open(walDir, options)
+	assert.NoError(t, err)
+	err = log.Replay(func(record wal.Record) error {
+		assert.Greater(t, record.TxID, txID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, log.Close())
+
+	restored, err := LoadSnapshot(path, WithWAL(walDir, options))
+	assert.NoError(t, err)
+	defer restored.Close()
+
+	value, _ := restored.Get(ctx, "key1")
+	assert.Nil(t, value)
+
+	value, err = restored.Get(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Equal(t, "afterSnapshot", value)
+}
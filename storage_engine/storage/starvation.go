@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"sort"
+)
+
+// DefaultStarvationThreshold is how many conflict aborts in a row a
+// transaction identity suffers before its next transaction reserves its keys,
+// unless WithStarvationThreshold says otherwise.
+const DefaultStarvationThreshold = 3
+
+// AbortStats is what the store knows about one transaction identity.
+type AbortStats struct {
+	Identity string
+	// ConsecutiveAborts counts the commit conflicts since the last successful
+	// commit, TotalAborts all of them.
+	ConsecutiveAborts int
+	TotalAborts       int
+	Commits           int
+	// Reservations counts the transactions that started with their keys
+	// reserved, FailedReservations those that could not because another
+	// transaction held a lock on one of the keys.
+	Reservations       int
+	FailedReservations int
+}
+
+type identityStats struct {
+	AbortStats
+	writeSet []string // keys written by the last attempt that conflicted
+}
+
+// identity returns the stats of name, creating them. Callers must hold the
+// write lock.
+func (s *memStore) identity(name string) *identityStats {
+	stats, ok := s.identities[name]
+	if !ok {
+		stats = &identityStats{AbortStats: AbortStats{Identity: name}}
+		s.identities[name] = stats
+	}
+	return stats
+}
+
+// reserveWriteSet locks, for a transaction of a starving identity, the keys
+// its last conflicting attempt wrote. They are locked as of the snapshot of
+// txID, so nobody can commit them before the transaction does. Callers must
+// hold the write lock.
+func (s *memStore) reserveWriteSet(txID int, name string) {
+	stats := s.identity(name)
+	if s.starvationThreshold <= 0 || stats.ConsecutiveAborts < s.starvationThreshold || len(stats.writeSet) == 0 {
+		return
+	}
+	if _, holder, _, ok := s.locks.tryAcquire(txID, stats.writeSet, txID); !ok {
+		stats.FailedReservations++
+		s.logger.Infof("Transaction %d (%s) cannot reserve its keys, transaction %d holds one", txID, name, holder)
+		return
+	}
+	stats.Reservations++
+	s.logger.Infof("Transaction %d (%s) reserved %v after %d aborts", txID, name, stats.writeSet, stats.ConsecutiveAborts)
+}
+
+// recordCommit updates the stats of identity name after a commit attempt that
+// wrote writeSet. Callers must hold the write lock.
+func (s *memStore) recordCommit(name string, writeSet []string, err error) {
+	if name == "" {
+		return
+	}
+	stats := s.identity(name)
+	switch {
+	case err == nil:
+		stats.Commits++
+		stats.ConsecutiveAborts = 0
+		stats.writeSet = nil
+	case isTxConflict(err):
+		stats.ConsecutiveAborts++
+		stats.TotalAborts++
+		stats.writeSet = writeSet
+	}
+}
+
+// AbortStats returns the stats of every transaction identity seen so far,
+// sorted by identity.
+func (s *memStore) AbortStats(ctx context.Context) []AbortStats {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	result := make([]AbortStats, 0, len(s.identities))
+	for _, stats := range s.identities {
+		result = append(result, stats.AbortStats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Identity < result[j].Identity })
+	return result
+}
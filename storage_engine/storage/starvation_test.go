@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemStorage_Starvation(t *testing.T) {
+	ctx := context.Background()
+
+	// attempt runs one increment of a for identity job, with an auto-commit
+	// sneaking in between its read and its commit
+	attempt := func(storage MemStorage) error {
+		tx := storage.Tx(WithIdentity("job"))
+		defer tx.Abort(ctx)
+
+		value, _ := tx.Get(ctx, "a")
+		_ = storage.Set(ctx, "a", value.(int)+100)
+		assert.NoError(t, tx.Set(ctx, "a", value.(int)+1))
+		return tx.Commit(ctx)
+	}
+
+	t.Run("A starving identity reserves its keys", func(t *testing.T) {
+		storage := NewMemStore(WithStarvationThreshold(2), WithLockWait(FailFast))
+		assert.NoError(t, storage.Set(ctx, "a", 0))
+
+		var conflict *appCommon.TxConflictError
+		assert.ErrorAs(t, attempt(storage), &conflict)
+		assert.ErrorAs(t, attempt(storage), &conflict)
+		// the third attempt holds a, so the sneaking write fails instead
+		assert.NoError(t, attempt(storage))
+
+		value, _ := storage.Get(ctx, "a")
+		assert.Equal(t, 201, value)
+		assert.Equal(t, []AbortStats{{
+			Identity:          "job",
+			ConsecutiveAborts: 0,
+			TotalAborts:       2,
+			Commits:           1,
+			Reservations:      1,
+		}}, storage.AbortStats(ctx))
+
+		// the reservation ended with the transaction
+		assert.NoError(t, storage.Set(ctx, "a", 0))
+	})
+
+	t.Run("A reservation needs every key free", func(t *testing.T) {
+		storage := NewMemStore(WithStarvationThreshold(1), WithLockWait(FailFast))
+		assert.NoError(t, storage.Set(ctx, "a", 0))
+		assert.Error(t, attempt(storage))
+
+		holder := storage.Tx()
+		assert.NoError(t, holder.Lock(ctx, "a"))
+		tx := storage.Tx(WithIdentity("job"))
+		assert.NoError(t, tx.Abort(ctx))
+		assert.NoError(t, holder.Abort(ctx))
+
+		stats := storage.AbortStats(ctx)[0]
+		assert.Equal(t, 1, stats.ConsecutiveAborts)
+		assert.Equal(t, 1, stats.FailedReservations)
+		assert.Zero(t, stats.Reservations)
+	})
+
+	t.Run("Transactions without identity are not tracked", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.Tx()
+		assert.NoError(t, tx.Set(ctx, "a", 1))
+		assert.NoError(t, tx.Commit(ctx))
+		assert.Empty(t, storage.AbortStats(ctx))
+	})
+}
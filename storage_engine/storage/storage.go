@@ -5,66 +5,239 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/btree"
+	"in-memory-storage-engine/storage_engine/index"
 	"in-memory-storage-engine/storage_engine/operation"
 	"in-memory-storage-engine/storage_engine/version"
+	"in-memory-storage-engine/storage_engine/wal"
+	"path"
 	"sync"
+	"time"
 )
 
 type MemStorage interface {
 	Set(ctx context.Context, key string, value interface{}) error
 	Get(ctx context.Context, key string) (interface{}, error)
 	Delete(ctx context.Context, key string) error
+	// CompareAndSwap sets key only if its latest version, the txID History
+	// reports last (0 for a key never written), is expectedVersion. It fails
+	// with *appCommon.VersionMismatchError carrying the current version if not.
+	CompareAndSwap(ctx context.Context, key string, expectedVersion int, value interface{}) error
+	// SetIfAbsent fails with *appCommon.VersionMismatchError if key has a value,
+	// SetIfPresent with KeyDoesNotExist if it has none.
+	SetIfAbsent(ctx context.Context, key string, value interface{}) error
+	SetIfPresent(ctx context.Context, key string, value interface{}) error
+	// DeleteIfVersion deletes key only if its latest version is expectedVersion.
+	DeleteIfVersion(ctx context.Context, key string, expectedVersion int) error
+	// SetWithTTL sets key to a value that reads as absent once ttl has passed.
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// Expire gives the current value of key a new ttl, Persist removes it.
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	Persist(ctx context.Context, key string) error
+	// TTL returns the time key has left, NoTTL if it never expires.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// RemoveExpiredKeys writes a delete version for every key expired by now,
+	// so the expiry shows up in the MVCC history.
+	RemoveExpiredKeys(ctx context.Context) error
+	// Keys returns the keys with a visible committed value matching the glob
+	// pattern (path.Match syntax), sorted.
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	// Scan returns the visible keys in [start, end) in order, at most limit of
+	// them (limit <= 0 means no limit). An empty end means no upper bound.
+	Scan(ctx context.Context, start, end string, limit int) ([]KeyValue, error)
+	ScanPrefix(ctx context.Context, prefix string) ([]KeyValue, error)
+	// History returns the committed version chain of key, oldest first.
+	History(ctx context.Context, key string) ([]version.VersionInfo, error)
+	// Transactions lists the open transactions with their pending writes.
+	Transactions(ctx context.Context) []TransactionInfo
+	// AbortTransaction aborts an open transaction from outside, e.g. a stuck one.
+	AbortTransaction(ctx context.Context, txID int) error
+	// RemoveExpiredTransactions aborts the transactions past their deadline.
+	// Tx and the version collector also do it, so an abandoned transaction
+	// does not hold back garbage collection for long.
+	RemoveExpiredTransactions(ctx context.Context) error
+	// RemoveOldVersionTransaction drops the versions older than the oldest
+	// snapshot still in use, and the keys that are deleted as of that snapshot.
 	RemoveOldVersionTransaction(ctx context.Context) error
-	Tx() MemTx
+	// CreateIndex registers a secondary index fed by extract, which must be a
+	// pure function of the value. Indexes are not persisted, create them again
+	// after reopening a store.
+	CreateIndex(ctx context.Context, name string, extract index.Func) error
+	// CreateUniqueIndex is CreateIndex for an index no two live keys may share
+	// a tuple of. Commits breaking that fail with *appCommon.UniqueViolationError,
+	// as does creating it over data that already does.
+	CreateUniqueIndex(ctx context.Context, name string, extract index.Func) error
+	DropIndex(ctx context.Context, name string) error
+	// LookupIndex returns the visible keys, in index order, having an index key
+	// equal to tuple (or starting with it, for a composite index).
+	LookupIndex(ctx context.Context, name string, tuple index.Tuple) ([]KeyValue, error)
+	// RangeIndex returns the visible keys having an index key in [start, end),
+	// at most limit of them (limit <= 0 means no limit). A nil end means no
+	// upper bound.
+	RangeIndex(ctx context.Context, name string, start, end index.Tuple, limit int) ([]KeyValue, error)
+	// Subscribe streams the commits made after fromTxID, see Subscription.
+	Subscribe(ctx context.Context, fromTxID int) (Subscription, error)
+	// Watch and WatchPrefix report the later changes of key, or of the keys
+	// starting with prefix, see Watcher.
+	Watch(ctx context.Context, key string) (Watcher, error)
+	WatchPrefix(ctx context.Context, prefix string) (Watcher, error)
+	// AbortStats reports the commit conflicts and key reservations of each
+	// transaction identity, see WithIdentity.
+	AbortStats(ctx context.Context) []AbortStats
+	// MemoryUsage reports the estimated memory in use when a limit is set.
+	MemoryUsage(ctx context.Context) MemoryUsage
+	// Tx starts a transaction, which expires appCommon.TransactionTimeout after
+	// it starts unless WithTimeout or WithContext say otherwise. Operations on
+	// an expired transaction fail with *appCommon.TxExpiredError.
+	Tx(opts ...TxOption) MemTx
+	// Update runs fn in a transaction and commits it, retrying on conflicts.
+	// View runs fn in a read-only transaction.
+	Update(ctx context.Context, fn func(tx MemTx) error, opts ...TxOption) error
+	View(ctx context.Context, fn func(tx MemTx) error) error
+	// ReadTx starts a read-only transaction at the latest committed txID. It
+	// rejects Set and Delete with ReadOnlyTxError and never conflicts. It
+	// expires like a transaction from Tx, which releases its snapshot.
+	ReadTx() MemTx
+	Snapshot(ctx context.Context, path string) (int, error)
+	Close() error
 }
 
-var globalTransactionCount = 0
-
 type memStore struct {
 	data                      map[string]version.VersionManager
+	keys                      *btree.BTree[struct{}] // ordered index over the keys of data
+	expiring                  map[string]time.Time   // expiry of the keys whose latest version has a TTL
+	indexes                   map[string]index.Index
+	uniqueIndexes             map[string]struct{}
 	affectedKeysInTransaction map[int]operation.KeyStore
+	transactionStartedAt      map[int]time.Time
+	transactionDeadline       map[int]time.Time // only transactions with a deadline
 	rwMutex                   *sync.RWMutex
 	logger                    *logrus.Logger
+	clock                     version.Clock
+	pins                      map[int]int // snapshot txID -> read-only users (ReadTx, Snapshot)
+	readTxs                   map[*readTx]struct{}
+	pinMutex                  *sync.Mutex // guards pins and readTxs
+	memory                    *memoryAccounting
+	locks                     *lockTable
+	lockWait                  LockWaitPolicy
+	identities                map[string]*identityStats
+	starvationThreshold       int
+	changelog                 *changelog
+	changelogSize             int
+	watchers                  *watchHub
+	walDir                    string
+	walOptions                wal.Options
+	wal                       wal.Log
+}
+
+// NewMemStore creates a store configured by opts. It panics if a configured WAL
+// cannot be opened or replayed, use OpenMemStore to handle that error instead.
+func NewMemStore(opts ...Option) MemStorage {
+	store, err := OpenMemStore(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return store
+}
+
+// OpenMemStore creates a store configured by opts. When a WAL directory is set,
+// the log is replayed to rebuild every key before the store is returned.
+func OpenMemStore(opts ...Option) (MemStorage, error) {
+	s := newMemStore(opts...)
+	if s.walDir != "" {
+		if err := s.openWAL(0); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
-func NewMemStore() MemStorage {
-	globalTransactionCount = 0
+func newMemStore(opts ...Option) *memStore {
 	logger := logrus.New()
 	logger.SetLevel(logrus.PanicLevel)
 	logger.SetFormatter(&logrus.TextFormatter{
 		ForceColors: true,
 	})
 
-	return &memStore{
+	s := &memStore{
 		data:                      make(map[string]version.VersionManager),
+		keys:                      btree.New[struct{}](btree.DefaultDegree),
+		expiring:                  make(map[string]time.Time),
+		indexes:                   make(map[string]index.Index),
+		uniqueIndexes:             make(map[string]struct{}),
 		rwMutex:                   new(sync.RWMutex),
 		affectedKeysInTransaction: make(map[int]operation.KeyStore),
+		transactionStartedAt:      make(map[int]time.Time),
+		transactionDeadline:       make(map[int]time.Time),
+		pins:                      make(map[int]int),
+		readTxs:                   make(map[*readTx]struct{}),
+		pinMutex:                  new(sync.Mutex),
+		memory:                    newMemoryAccounting(),
+		locks:                     newLockTable(),
+		identities:                make(map[string]*identityStats),
+		starvationThreshold:       DefaultStarvationThreshold,
+		changelogSize:             DefaultChangelogSize,
+		watchers:                  newWatchHub(),
 		logger:                    logger,
+		clock:                     version.NewClock(0),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.changelog = newChangelog(s.changelogSize)
+	return s
 }
 
-func (s *memStore) Tx() MemTx {
+func (s *memStore) Tx(opts ...TxOption) MemTx {
+	options := newTxOptions(opts)
+	if options.readOnly {
+		return s.startReadTx(options)
+	}
+
 	s.rwMutex.Lock()
 	defer s.rwMutex.Unlock()
 
-	increaseGlobalTransactionCount()
-	s.makeMapOperationIfNotExist(globalTransactionCount)
-	s.logger.Infof("Transaction %d starts", globalTransactionCount)
+	s.reapExpiredTransactions(time.Now())
+	txID := s.clock.Next()
+	s.makeMapOperationIfNotExist(txID)
+	s.logger.Infof("Transaction %d starts", txID)
 
-	return &memTx{
-		memStore: s,
-		txID:     globalTransactionCount,
-		rwLock:   new(sync.RWMutex),
+	tx := &memTx{
+		memStore:    s,
+		txID:        txID,
+		startedAt:   s.transactionStartedAt[txID],
+		rwLock:      new(sync.RWMutex),
+		isolation:   options.isolation,
+		watchedKeys: make(map[string]struct{}),
+		deadline:    options.deadline(s.transactionStartedAt[txID]),
+		ctx:         options.ctx,
+		lockWait:    s.lockWait,
+		identity:    options.identity,
+	}
+	if options.lockWait != nil {
+		tx.lockWait = *options.lockWait
+	}
+	if options.identity != "" {
+		s.reserveWriteSet(txID, options.identity)
+	}
+	if !tx.deadline.IsZero() {
+		s.transactionDeadline[txID] = tx.deadline
+	}
+	if options.ctx != nil {
+		tx.stop = context.AfterFunc(options.ctx, func() {
+			s.expireTransaction(txID)
+		})
 	}
+	return tx
 }
 
 func (s *memStore) Set(ctx context.Context, key string, value interface{}) error {
-	s.rwMutex.Lock()
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
 	defer s.rwMutex.Unlock()
 
-	increaseGlobalTransactionCount()
-	s.setInternal(ctx, key, value, globalTransactionCount)
-	return nil
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, value, time.Time{})})
 }
 
 func (s *memStore) Get(ctx context.Context, key string) (interface{}, error) {
@@ -74,21 +247,95 @@ func (s *memStore) Get(ctx context.Context, key string) (interface{}, error) {
 	if !s.checkKeyExist(key) {
 		return nil, appCommon.KeyDoesNotExist
 	}
+	s.touchKey(key)
 	return s.data[key].GetCommitted(ctx), nil
 }
 
 func (s *memStore) Delete(ctx context.Context, key string) error {
-	s.rwMutex.Lock()
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
 	defer s.rwMutex.Unlock()
-	increaseGlobalTransactionCount()
 
-	return s.deleteInternal(ctx, key, globalTransactionCount)
+	if !s.checkKeyVisible(ctx, key) {
+		s.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newDeleteEntry(key)})
+}
+
+func (s *memStore) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid key pattern %q: %w", pattern, err)
+	}
+
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	keys := make([]string, 0)
+	s.keys.Ascend("", "", func(key string, _ struct{}) bool {
+		if matched, _ := path.Match(pattern, key); matched && s.data[key].GetCommitted(ctx) != nil {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys, nil
+}
+
+func (s *memStore) History(ctx context.Context, key string) ([]version.VersionInfo, error) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	if !s.checkKeyExist(key) {
+		return nil, appCommon.KeyDoesNotExist
+	}
+	return s.data[key].History(ctx), nil
 }
 
 func (s *memStore) makeMapOperationIfNotExist(txID int) {
 	_, exist := s.affectedKeysInTransaction[txID]
 	if !exist {
 		s.affectedKeysInTransaction[txID] = operation.NewOperationsKeyStore()
+		s.transactionStartedAt[txID] = time.Now()
+	}
+}
+
+func (s *memStore) removeTransaction(txID int) {
+	delete(s.affectedKeysInTransaction, txID)
+	delete(s.transactionStartedAt, txID)
+	delete(s.transactionDeadline, txID)
+	s.locks.release(txID)
+}
+
+func (s *memStore) RemoveExpiredTransactions(ctx context.Context) error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	s.reapExpiredTransactions(time.Now())
+	return nil
+}
+
+// reapExpiredTransactions aborts the transactions whose deadline is past now
+// and releases the snapshots of the expired read-only ones.
+// Callers must hold the write lock.
+func (s *memStore) reapExpiredTransactions(now time.Time) {
+	for txID, deadline := range s.transactionDeadline {
+		if !now.Before(deadline) {
+			s.logger.Infof("Transaction %d expired at %v, aborting it", txID, deadline)
+			s.removeTransaction(txID)
+		}
+	}
+	s.reapExpiredReadTxs(now)
+}
+
+// expireTransaction aborts txID once the context it was started with is done.
+func (s *memStore) expireTransaction(txID int) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if s.checkTxExist(txID) {
+		s.logger.Infof("Context of transaction %d is done, aborting it", txID)
+		s.removeTransaction(txID)
 	}
 }
 
@@ -96,12 +343,88 @@ func (s *memStore) RemoveOldVersionTransaction(ctx context.Context) error {
 	s.rwMutex.Lock()
 	defer s.rwMutex.Unlock()
 
-	for key, _ := range s.data {
-		if err := s.data[key].RemoveOldVersion(ctx); err != nil {
+	return s.removeOldVersions(ctx)
+}
+
+// removeOldVersions must be called while holding the write lock.
+func (s *memStore) removeOldVersions(ctx context.Context) error {
+	s.reapExpiredTransactions(time.Now())
+	watermark := s.watermark()
+	for _, idx := range s.indexes {
+		idx.Prune(watermark)
+	}
+	removed := 0
+	for key, manager := range s.data {
+		empty, err := manager.RemoveOldVersion(ctx, watermark)
+		if err != nil {
 			s.logger.WithContext(ctx).Errorln(err)
 			return fmt.Errorf("there are some errors when running clean up process: %w", err)
 		}
+		if empty {
+			s.dropKey(key)
+			removed++
+		} else {
+			s.recountKey(ctx, key)
+		}
 	}
 
+	s.logger.Infof("Removed versions before transaction %d, %d deleted keys dropped", watermark, removed)
 	return nil
 }
+
+// dropKey forgets every version of key. Callers must hold the write lock.
+func (s *memStore) dropKey(key string) {
+	delete(s.data, key)
+	delete(s.expiring, key)
+	s.keys.Delete(key)
+	s.forgetKeyUsage(key)
+	for _, idx := range s.indexes {
+		idx.Remove(key)
+	}
+}
+
+// watermark is the oldest snapshot txID still readable: the smallest open
+// transaction, pinned read-only snapshot or, without any, the current txID.
+// Callers must hold the write lock.
+func (s *memStore) watermark() int {
+	watermark := s.clock.Current()
+	for txID := range s.affectedKeysInTransaction {
+		watermark = min(watermark, txID)
+	}
+
+	s.pinMutex.Lock()
+	defer s.pinMutex.Unlock()
+	for txID := range s.pins {
+		watermark = min(watermark, txID)
+	}
+	return watermark
+}
+
+// pin keeps the versions visible at txID from being collected. Callers must
+// hold at least the read lock so the collector cannot run in between.
+func (s *memStore) pin(txID int) {
+	s.pinMutex.Lock()
+	defer s.pinMutex.Unlock()
+	s.pins[txID]++
+}
+
+func (s *memStore) unpin(txID int) {
+	s.pinMutex.Lock()
+	defer s.pinMutex.Unlock()
+	s.pins[txID]--
+	if s.pins[txID] <= 0 {
+		delete(s.pins, txID)
+	}
+}
+
+func (s *memStore) Close() error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+	err := s.wal.Close()
+	s.wal = nil
+	return err
+}
@@ -2,20 +2,25 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"in-memory-storage-engine/appCommon"
 	"in-memory-storage-engine/storage_engine/operation"
 	"in-memory-storage-engine/storage_engine/version"
+	"in-memory-storage-engine/storage_engine/wal"
+	"sort"
+	"time"
 )
 
-func increaseGlobalTransactionCount() {
-	globalTransactionCount++
-}
-
 func (s *memStore) checkKeyExist(key string) bool {
 	_, exist := s.data[key]
 	return exist
 }
 
+// checkKeyVisible reports whether the latest committed version of key holds a value.
+func (s *memStore) checkKeyVisible(ctx context.Context, key string) bool {
+	return s.checkKeyExist(key) && s.data[key].GetCommitted(ctx) != nil
+}
+
 func (s *memStore) checkKeyExistInTransaction(txID int, key string) (bool, error) {
 	if !s.checkTxExist(txID) {
 		s.logger.Errorln(appCommon.NewTxIDDoesNotExistError(txID))
@@ -36,11 +41,22 @@ func (s *memStore) checkTxExistWithLock(txID int) bool {
 	return exist
 }
 
-func (s *memStore) setInternal(ctx context.Context, key string, value interface{}, txID int) {
+func (s *memStore) setInternal(ctx context.Context, key string, value interface{}, txID int, expireAt time.Time) {
 	if !s.checkKeyExist(key) {
 		s.data[key] = version.NewValueVersionManager()
+		s.keys.Set(key, struct{}{})
+	}
+	s.data[key].Set(ctx, value, txID, expireAt)
+	s.accountVersion(key, value)
+	for _, idx := range s.indexes {
+		idx.Set(key, value, txID)
+	}
+
+	if expireAt.IsZero() {
+		delete(s.expiring, key)
+	} else {
+		s.expiring[key] = expireAt
 	}
-	s.data[key].Set(ctx, value, txID)
 }
 
 func (s *memStore) deleteInternal(ctx context.Context, key string, txID int) error {
@@ -48,36 +64,105 @@ func (s *memStore) deleteInternal(ctx context.Context, key string, txID int) err
 		s.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
 		return appCommon.KeyDoesNotExist
 	}
-	return s.data[key].Delete(ctx, txID)
+	delete(s.expiring, key)
+	if err := s.data[key].Delete(ctx, txID); err != nil {
+		return err
+	}
+	s.accountVersion(key, nil)
+	for _, idx := range s.indexes {
+		idx.Delete(key, txID)
+	}
+	return nil
 }
 
 func (s *memStore) checkIfTransactionCanBeCommited(ctx context.Context, txID int) error {
 	for key, _ := range *s.affectedKeysInTransaction[txID].GetAllOperation() {
-		if s.checkKeyExist(key) {
-			keyTxID, err := s.data[key].GetLatestVersionForKey(ctx)
-			if err != nil {
-				s.logger.WithContext(ctx).Errorln(err)
-				return err
-			}
-			if keyTxID > txID {
-				return appCommon.NewTxIDCanNotBeCommited(txID)
-			}
+		if err := s.checkKeyNotCommittedAfter(ctx, key, s.locks.readAt(txID, key)); err != nil {
+			return err
 		}
 	}
+	return s.checkUniqueIndexes(ctx, s.transactionEntries(txID))
+}
+
+// checkKeyNotCommittedAfter fails if key has a version newer than txID.
+func (s *memStore) checkKeyNotCommittedAfter(ctx context.Context, key string, txID int) error {
+	if !s.checkKeyExist(key) {
+		return nil
+	}
+	keyTxID, err := s.data[key].GetLatestVersionForKey(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	if keyTxID > txID {
+		return appCommon.NewTxIDCanNotBeCommited(txID)
+	}
 	return nil
 }
 
 func (s *memStore) applyTransaction(ctx context.Context, txID int) error {
-	increaseGlobalTransactionCount()
-	for key, value := range *s.affectedKeysInTransaction[txID].GetAllOperation() {
-		switch value.OperationType {
+	return s.commitBatch(ctx, txID, s.transactionEntries(txID))
+}
+
+// transactionEntries returns the write set of txID in key order.
+func (s *memStore) transactionEntries(txID int) []wal.Entry {
+	operations := *s.affectedKeysInTransaction[txID].GetAllOperation()
+	keys := make([]string, 0, len(operations))
+	for key := range operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]wal.Entry, 0, len(keys))
+	for _, key := range keys {
+		switch operations[key].OperationType {
 		case operation.DELETE:
-			_ = s.deleteInternal(ctx, key, globalTransactionCount)
-			continue
+			entries = append(entries, newDeleteEntry(key))
 		case operation.SET:
-			s.setInternal(ctx, key, value.Value, globalTransactionCount)
-			continue
+			entries = append(entries, newSetEntry(key, operations[key].Value, operations[key].ExpireAt))
 		}
 	}
+	return entries
+}
+
+// noTransaction is the committer of auto-committed writes.
+const noTransaction = 0
+
+// commitBatch makes entries durable in the WAL (if any) and then applies them
+// under a new txID. committer is the transaction being committed, its own
+// snapshot does not stop eviction. Callers must hold the write lock.
+func (s *memStore) commitBatch(ctx context.Context, committer int, entries []wal.Entry) error {
+	// a transaction checked its write set in checkIfTransactionCanBeCommited
+	if committer == noTransaction {
+		if err := s.checkUniqueIndexes(ctx, entries); err != nil {
+			s.logger.WithContext(ctx).Errorln(err)
+			return err
+		}
+	}
+	if err := s.reserveMemory(ctx, committer, entries); err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+
+	record := wal.Record{TxID: s.clock.Next(), Entries: entries, CommittedAt: time.Now()}
+	if s.wal != nil {
+		if err := s.wal.Append(record); err != nil {
+			s.logger.WithContext(ctx).Errorln(err)
+			return fmt.Errorf("cannot write transaction %d to wal: %w", record.TxID, err)
+		}
+	}
+	s.applyEntries(ctx, record.TxID, entries)
+	s.publish(record)
 	return nil
 }
+
+func (s *memStore) applyEntries(ctx context.Context, txID int, entries []wal.Entry) {
+	for _, entry := range entries {
+		switch entry.OperationType {
+		case operation.DELETE:
+			_ = s.deleteInternal(ctx, entry.Key, txID)
+		case operation.SET:
+			s.setInternal(ctx, entry.Key, entry.Value, txID, entry.ExpireAt)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"in-memory-storage-engine/appCommon"
 	"strconv"
 	"sync"
 	"testing"
@@ -89,6 +90,42 @@ func TestMemStorage_Transactions(t *testing.T) {
 		globalValue, _ := storage.Get(ctx, "key5")
 		assert.Nil(t, globalValue)
 	})
+
+	t.Run("Delete committed key in a Transaction", func(t *testing.T) {
+		assert.NoError(t, storage.Set(ctx, "key6", "committedValue"))
+
+		txID := storage.Tx()
+		err := txID.Delete(ctx, "key6")
+		assert.NoError(t, err)
+
+		value, err := txID.Get(ctx, "key6")
+		assert.NoError(t, err)
+		assert.Nil(t, value, "a transaction must read its own delete")
+
+		globalValue, _ := storage.Get(ctx, "key6")
+		assert.Equal(t, "committedValue", globalValue)
+
+		assert.NoError(t, txID.Commit(ctx))
+		globalValue, _ = storage.Get(ctx, "key6")
+		assert.Nil(t, globalValue)
+	})
+
+	t.Run("Watched key modified after transaction start", func(t *testing.T) {
+		assert.NoError(t, storage.Set(ctx, "key7", "before"))
+
+		txID := storage.Tx()
+		assert.NoError(t, txID.Watch(ctx, "key7"))
+		assert.NoError(t, txID.Set(ctx, "key8", "dependsOnKey7"))
+
+		assert.NoError(t, storage.Set(ctx, "key7", "after"))
+
+		err := txID.Commit(ctx)
+		var conflict *appCommon.TxConflictError
+		assert.ErrorAs(t, err, &conflict)
+
+		globalValue, _ := storage.Get(ctx, "key8")
+		assert.Nil(t, globalValue)
+	})
 }
 
 func BenchmarkMemStore_ConcurrentTransactionScaling(b *testing.B) {
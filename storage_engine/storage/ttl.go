@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/version"
+	"in-memory-storage-engine/storage_engine/wal"
+	"sort"
+	"time"
+)
+
+// NoTTL is what TTL returns for a key that never expires.
+const NoTTL time.Duration = -1
+
+func (s *memStore) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	expireAt, err := expireAfter(ttl)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, value, expireAt)})
+}
+
+func (s *memStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	expireAt, err := expireAfter(ttl)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	info, ok := s.latestVersion(ctx, key)
+	if !ok {
+		s.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, info.Value, expireAt)})
+}
+
+func (s *memStore) Persist(ctx context.Context, key string) error {
+	if err := s.lockForWrite(ctx, nil, s.lockWait, key); err != nil {
+		return err
+	}
+	defer s.rwMutex.Unlock()
+
+	info, ok := s.latestVersion(ctx, key)
+	if !ok {
+		s.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	if info.ExpireAt.IsZero() {
+		return nil
+	}
+	return s.commitBatch(ctx, noTransaction, []wal.Entry{newSetEntry(key, info.Value, time.Time{})})
+}
+
+func (s *memStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	info, ok := s.latestVersion(ctx, key)
+	if !ok {
+		return 0, appCommon.KeyDoesNotExist
+	}
+	return remainingTTL(info.ExpireAt), nil
+}
+
+func (s *memStore) RemoveExpiredKeys(ctx context.Context) error {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0)
+	for key, expireAt := range s.expiring {
+		// a locked key is left to the next sweep
+		if expired(expireAt, now) && !s.locks.isLocked(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	entries := make([]wal.Entry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, newDeleteEntry(key))
+	}
+	if err := s.commitBatch(ctx, noTransaction, entries); err != nil {
+		return err
+	}
+	s.logger.Infof("Removed %d expired keys", len(keys))
+	return nil
+}
+
+// latestVersion returns the latest committed version of key if it is still
+// live. Callers must hold at least the read lock.
+func (s *memStore) latestVersion(ctx context.Context, key string) (version.VersionInfo, bool) {
+	if !s.checkKeyExist(key) {
+		return version.VersionInfo{}, false
+	}
+	return s.data[key].GetVersionBeforeTransaction(ctx, s.clock.Current(), time.Now())
+}
+
+func (tx *memTx) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	expireAt, err := expireAfter(ttl)
+	if err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+
+	tx.keyStore().Put(key, newExpiringSetOperation(value, expireAt))
+	return nil
+}
+
+func (tx *memTx) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	expireAt, err := expireAfter(ttl)
+	if err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+
+	value, _, ok := tx.lookup(ctx, key)
+	if !ok {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	tx.keyStore().Put(key, newExpiringSetOperation(value, expireAt))
+	return nil
+}
+
+func (tx *memTx) Persist(ctx context.Context, key string) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+
+	value, expireAt, ok := tx.lookup(ctx, key)
+	if !ok {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
+		return appCommon.KeyDoesNotExist
+	}
+	if expireAt.IsZero() {
+		return nil
+	}
+	tx.keyStore().Put(key, newExpiringSetOperation(value, time.Time{}))
+	return nil
+}
+
+func (tx *memTx) TTL(ctx context.Context, key string) (time.Duration, error) {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return 0, err
+	}
+
+	_, expireAt, ok := tx.lookup(ctx, key)
+	if !ok {
+		return 0, appCommon.KeyDoesNotExist
+	}
+	return remainingTTL(expireAt), nil
+}
+
+func newExpiringSetOperation(value interface{}, expireAt time.Time) operation.Operation {
+	return operation.Operation{
+		OperationType: operation.SET,
+		Value:         value,
+		ExpireAt:      expireAt,
+	}
+}
+
+// pendingValue returns the value a pending operation leaves for its key at
+// time at. The last result is false for a delete or an expired value.
+func pendingValue(op operation.Operation, at time.Time) (interface{}, bool) {
+	if op.OperationType != operation.SET || op.Value == nil || expired(op.ExpireAt, at) {
+		return nil, false
+	}
+	return op.Value, true
+}
+
+func expireAfter(ttl time.Duration) (time.Time, error) {
+	if ttl <= 0 {
+		return time.Time{}, appCommon.InvalidTTL
+	}
+	return time.Now().Add(ttl), nil
+}
+
+func expired(expireAt, at time.Time) bool {
+	return !expireAt.IsZero() && !at.Before(expireAt)
+}
+
+func remainingTTL(expireAt time.Time) time.Duration {
+	if expireAt.IsZero() {
+		return NoTTL
+	}
+	return max(time.Until(expireAt), 0)
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func TestMemStorage_TTL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Expired key reads as absent", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.SetWithTTL(ctx, "session", "token", 20*time.Millisecond))
+
+		ttl, err := storage.TTL(ctx, "session")
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+
+		time.Sleep(30 * time.Millisecond)
+
+		value, err := storage.Get(ctx, "session")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+		_, err = storage.TTL(ctx, "session")
+		assert.Equal(t, appCommon.KeyDoesNotExist, err)
+		assert.Equal(t, appCommon.KeyDoesNotExist, storage.Delete(ctx, "session"))
+	})
+
+	t.Run("Expire and Persist", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+
+		ttl, err := storage.TTL(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, NoTTL, ttl)
+
+		assert.NoError(t, storage.Expire(ctx, "a", time.Hour))
+		ttl, _ = storage.TTL(ctx, "a")
+		assert.Greater(t, ttl, 59*time.Minute)
+
+		assert.NoError(t, storage.Persist(ctx, "a"))
+		ttl, _ = storage.TTL(ctx, "a")
+		assert.Equal(t, NoTTL, ttl)
+
+		assert.Equal(t, appCommon.KeyDoesNotExist, storage.Expire(ctx, "missing", time.Hour))
+		assert.Equal(t, appCommon.InvalidTTL, storage.Expire(ctx, "a", 0))
+	})
+
+	t.Run("Snapshot taken before expiry still sees the key", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.SetWithTTL(ctx, "a", 1, 20*time.Millisecond))
+
+		tx := storage.Tx()
+		time.Sleep(30 * time.Millisecond)
+
+		value, err := tx.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, value)
+
+		after := storage.ReadTx()
+		value, err = after.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("Sweeper writes a delete version", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.SetWithTTL(ctx, "a", 1, 10*time.Millisecond))
+		assert.NoError(t, storage.Set(ctx, "b", 2))
+		time.Sleep(20 * time.Millisecond)
+
+		assert.NoError(t, storage.RemoveExpiredKeys(ctx))
+
+		history, err := storage.History(ctx, "a")
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.False(t, history[1].Visible)
+
+		history, _ = storage.History(ctx, "b")
+		assert.Len(t, history, 1)
+	})
+
+	t.Run("TTL survives wal replay", func(t *testing.T) {
+		dir := t.TempDir()
+		options := wal.Options{SyncPolicy: wal.SyncEveryCommit}
+
+		store, err := OpenMemStore(WithWAL(dir, options))
+		assert.NoError(t, err)
+		assert.NoError(t, store.SetWithTTL(ctx, "a", 1, time.Hour))
+		assert.NoError(t, store.Close())
+
+		restored, err := OpenMemStore(WithWAL(dir, options))
+		assert.NoError(t, err)
+		defer restored.Close()
+
+		ttl, err := restored.TTL(ctx, "a")
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, 59*time.Minute)
+	})
+
+	t.Run("TTL inside a transaction", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+
+		tx := storage.Tx()
+		assert.NoError(t, tx.Expire(ctx, "a", time.Hour))
+		assert.NoError(t, tx.SetWithTTL(ctx, "b", 2, time.Hour))
+
+		ttl, err := storage.TTL(ctx, "a")
+		assert.NoError(t, err)
+		assert.Equal(t, NoTTL, ttl)
+		ttl, err = tx.TTL(ctx, "a")
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, 59*time.Minute)
+
+		assert.NoError(t, tx.Commit(ctx))
+
+		ttl, _ = storage.TTL(ctx, "b")
+		assert.Greater(t, ttl, 59*time.Minute)
+		value, _ := storage.Get(ctx, "a")
+		assert.Equal(t, 1, value)
+	})
+}
@@ -3,51 +3,141 @@ package storage
 import (
 	"context"
 	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/index"
+	"in-memory-storage-engine/storage_engine/operation"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type MemTx interface {
+	// ID returns the txID the transaction reads its snapshot at.
+	ID() int
 	Set(ctx context.Context, key string, value interface{}) error
 	Get(ctx context.Context, key string) (interface{}, error)
 	Delete(ctx context.Context, key string) error
+	// SetWithTTL, Expire, Persist and TTL work like their MemStorage
+	// counterparts on the transaction's view of the key.
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	Persist(ctx context.Context, key string) error
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Scan and ScanPrefix see the transaction's own pending writes merged
+	// with the snapshot at its txID.
+	Scan(ctx context.Context, start, end string, limit int) ([]KeyValue, error)
+	ScanPrefix(ctx context.Context, prefix string) ([]KeyValue, error)
+	// Watch makes Commit fail if any of keys gets committed by someone else
+	// after this transaction started, even if this transaction never writes it.
+	Watch(ctx context.Context, keys ...string) error
+	// LookupIndex and RangeIndex query an index at the transaction's snapshot,
+	// its own pending writes included.
+	LookupIndex(ctx context.Context, name string, tuple index.Tuple) ([]KeyValue, error)
+	RangeIndex(ctx context.Context, name string, start, end index.Tuple, limit int) ([]KeyValue, error)
+	// Lock takes exclusive locks on keys until the transaction ends, and
+	// GetForUpdate locks key and reads it. See memTx.Lock.
+	Lock(ctx context.Context, keys ...string) error
+	GetForUpdate(ctx context.Context, key string) (interface{}, error)
+	// Begin starts a child transaction that sees the pending writes of this
+	// one and merges its own into them when it commits.
+	Begin() MemTx
+	// Savepoint marks the pending writes under name, RollbackTo brings them
+	// back to that mark (deletes included) and Release forgets it, see
+	// operation.KeyStore. Neither touches the snapshot or the read set.
+	Savepoint(ctx context.Context, name string) error
+	RollbackTo(ctx context.Context, name string) error
+	Release(ctx context.Context, name string) error
 	Commit(ctx context.Context) error
 	Abort(ctx context.Context) error
 }
 
 type memTx struct {
-	memStore *memStore
-	txID     int
-	rwLock   *sync.RWMutex
+	memStore  *memStore
+	txID      int
+	startedAt time.Time // values expired by then are absent from the snapshot
+	rwLock    *sync.RWMutex
+	isolation IsolationLevel
+	// deadline (if set) and ctx (if done) expire the transaction, see expired
+	deadline time.Time
+	ctx      context.Context
+	stop     func() bool // stops aborting the transaction when ctx is done
+	lockWait LockWaitPolicy
+	identity string // see WithIdentity
+	// watchedKeys and readRanges form the read set validated at commit: the
+	// keys passed to Watch plus, under Serializable, everything read.
+	watchedKeys map[string]struct{}
+	readRanges  []keyRange
+	// a child transaction (see Begin) keeps its writes in operations until it
+	// commits into parent, everything else belongs to the outermost one
+	parent     *memTx
+	operations operation.KeyStore
+	done       atomic.Bool
 }
 
+// keyRange is [start, end), an empty end means no upper bound.
+type keyRange struct {
+	start string
+	end   string
+}
+
+func (tx *memTx) ID() int {
+	return tx.txID
+}
+
+// Abort ignores whether ctx is done, so it can clean up after a cancellation.
+// An expired transaction is dropped all the same but reports TxExpiredError.
 func (tx *memTx) Abort(ctx context.Context) error {
+	if tx.parent != nil {
+		return tx.abortChild(ctx)
+	}
+
 	tx.memStore.rwMutex.Lock()
 	defer tx.memStore.rwMutex.Unlock()
 
-	if !tx.memStore.checkTxExist(tx.txID) {
+	exist := tx.memStore.checkTxExist(tx.txID)
+	if exist {
+		tx.memStore.logger.Infof("Aborting transaction %d", tx.txID)
+		tx.finish()
+	}
+	if tx.expired(time.Now()) {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxExpiredError(tx.txID))
+		return appCommon.NewTxExpiredError(tx.txID)
+	}
+	if !exist {
 		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.txID))
 		return appCommon.NewTxIDDoesNotExistError(tx.txID)
 	}
-
-	tx.memStore.logger.Infof("Aborting transaction %d", tx.txID)
-
-	delete(tx.memStore.affectedKeysInTransaction, tx.txID)
 	tx.memStore.logger.Infof("Aborted transaction %d successfully", tx.txID)
 	return nil
 }
 
 func (tx *memTx) Commit(ctx context.Context) error {
-	tx.memStore.rwMutex.Lock()
+	if tx.parent != nil {
+		return tx.commitChild(ctx)
+	}
+
+	writeSet := tx.writtenKeys()
+	if err := tx.memStore.lockForWrite(ctx, tx, tx.lockWait, writeSet...); err != nil {
+		return err
+	}
 	defer tx.memStore.rwMutex.Unlock()
 
-	if !tx.memStore.checkTxExist(tx.txID) {
-		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.txID))
-		return appCommon.NewTxIDDoesNotExistError(tx.txID)
+	if err := tx.checkOpen(ctx); err != nil {
+		if tx.expired(time.Now()) {
+			tx.finish()
+		}
+		return err
 	}
 
 	tx.memStore.logger.Infof("Transaction %d is being commited...", tx.txID)
 	if err := tx.memStore.checkIfTransactionCanBeCommited(ctx, tx.txID); err != nil {
 		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		tx.memStore.recordCommit(tx.identity, writeSet, err)
+		return err
+	}
+	if err := tx.checkWatchedKeys(ctx); err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		tx.memStore.recordCommit(tx.identity, writeSet, err)
 		return err
 	}
 	tx.memStore.logger.Infof("Applying transaction %d", tx.txID)
@@ -56,20 +146,79 @@ func (tx *memTx) Commit(ctx context.Context) error {
 		return err
 	}
 	tx.memStore.logger.Infof("Transaction %d is successfully committed", tx.txID)
-	delete(tx.memStore.affectedKeysInTransaction, tx.txID)
+	tx.memStore.recordCommit(tx.identity, writeSet, nil)
+	tx.finish()
 	return nil
 }
 
-func (tx *memTx) Set(ctx context.Context, key string, value interface{}) error {
+// writtenKeys returns the keys the transaction has pending writes for.
+func (tx *memTx) writtenKeys() []string {
 	tx.memStore.rwMutex.RLock()
 	defer tx.memStore.rwMutex.RUnlock()
 
+	if !tx.memStore.checkTxExist(tx.txID) {
+		return nil
+	}
+	operations := tx.keyStore().CopyOperations()
+	keys := make([]string, 0, len(operations))
+	for key := range operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkOpen fails if ctx is done, or if the transaction (or for a child, one
+// of its parents) has expired or is no longer open. Callers must hold at least the store read lock.
+func (tx *memTx) checkOpen(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		tx.memStore.logger.WithContext(ctx).Errorln(err)
+		return err
+	}
+	if tx.parent != nil {
+		if tx.done.Load() {
+			tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.txID))
+			return appCommon.NewTxIDDoesNotExistError(tx.txID)
+		}
+		return tx.parent.checkOpen(ctx)
+	}
+	if tx.expired(time.Now()) {
+		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxExpiredError(tx.txID))
+		return appCommon.NewTxExpiredError(tx.txID)
+	}
 	if !tx.memStore.checkTxExist(tx.txID) {
 		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.txID))
 		return appCommon.NewTxIDDoesNotExistError(tx.txID)
 	}
+	return nil
+}
+
+// expired reports whether the transaction is past its deadline or its context
+// is done.
+func (tx *memTx) expired(now time.Time) bool {
+	if !tx.deadline.IsZero() && !now.Before(tx.deadline) {
+		return true
+	}
+	return tx.ctx != nil && tx.ctx.Err() != nil
+}
+
+// finish forgets the transaction. Callers must hold the write lock.
+func (tx *memTx) finish() {
+	tx.memStore.removeTransaction(tx.txID)
+	if tx.stop != nil {
+		tx.stop()
+	}
+}
 
-	tx.memStore.affectedKeysInTransaction[tx.txID].Set(key, value)
+func (tx *memTx) Set(ctx context.Context, key string, value interface{}) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
+	}
+
+	tx.keyStore().Set(key, value)
 
 	tx.memStore.logger.Infof("Setting key %s with Value %v for tracsaction %d", key, value, tx.txID)
 	return nil
@@ -79,52 +228,121 @@ func (tx *memTx) Get(ctx context.Context, key string) (interface{}, error) {
 	tx.memStore.rwMutex.RLock()
 	defer tx.memStore.rwMutex.RUnlock()
 
-	if !tx.memStore.checkTxExist(tx.txID) {
-		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.txID))
-		return nil, appCommon.NewTxIDDoesNotExistError(tx.txID)
+	if err := tx.checkOpen(ctx); err != nil {
+		return nil, err
 	}
 
-	value := tx.memStore.affectedKeysInTransaction[tx.txID].Get(key)
-	if value != nil {
-		return value, nil
-	}
+	value, _, _ := tx.lookup(ctx, key)
+	return value, nil
+}
 
-	if tx.memStore.checkKeyExist(key) {
-		return tx.memStore.data[key].GetValueBeforeTransaction(ctx, tx.txID), nil
+// lookup returns what key holds for the transaction: its own pending write,
+// including deletes, or else the snapshot. The last result is false if the key
+// has no live value. Callers must hold at least the store read lock.
+func (tx *memTx) lookup(ctx context.Context, key string) (interface{}, time.Time, bool) {
+	if op, exist := tx.pendingOperation(key); exist {
+		value, ok := pendingValue(op, time.Now())
+		return value, op.ExpireAt, ok
 	}
 
-	return nil, nil
+	tx.trackRead(key)
+
+	if !tx.memStore.checkKeyExist(key) {
+		return nil, time.Time{}, false
+	}
+	tx.memStore.touchKey(key)
+	readAt := tx.memStore.locks.readAt(tx.txID, key)
+	info, ok := tx.memStore.data[key].GetVersionBeforeTransaction(ctx, readAt, tx.startedAt)
+	return info.Value, info.ExpireAt, ok
 }
 
 func (tx *memTx) Delete(ctx context.Context, key string) error {
 	tx.memStore.rwMutex.RLock()
 	defer tx.memStore.rwMutex.RUnlock()
 
-	if !tx.memStore.checkTxExist(tx.txID) {
-		tx.memStore.logger.WithContext(ctx).Errorln(appCommon.NewTxIDDoesNotExistError(tx.txID))
-		return appCommon.NewTxIDDoesNotExistError(tx.txID)
+	if err := tx.checkOpen(ctx); err != nil {
+		return err
 	}
 
-	// TODO: first need to check if key has been in transaction before or has been in current transaction
-	if !tx.memStore.affectedKeysInTransaction[tx.txID].CheckIfKeyExists(key) {
+	if _, pending := tx.pendingOperation(key); !pending {
 		if !tx.memStore.checkKeyExist(key) { // check if the key has been existed before
 			tx.memStore.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
 			return appCommon.KeyDoesNotExist
 		}
 
 		// if it exists then check for if it has been deleted (since we store multiple versions)
-		if tx.memStore.data[key].GetValueBeforeTransaction(ctx, tx.txID) != nil {
+		readAt := tx.memStore.locks.readAt(tx.txID, key)
+		if tx.memStore.data[key].GetValueBeforeTransaction(ctx, readAt, tx.startedAt) == nil {
 			tx.memStore.logger.WithContext(ctx).Errorln(appCommon.KeyDoesNotExist)
 			return appCommon.KeyDoesNotExist
 		}
 	}
 
 	tx.memStore.logger.Infof("Deleting key %s for transaction %d", key, tx.txID)
+	tx.keyStore().Put(key, operation.Operation{OperationType: operation.DELETE})
+	return nil
+}
 
-	if err := tx.memStore.affectedKeysInTransaction[tx.txID].Delete(key); err != nil {
-		tx.memStore.logger.WithContext(ctx).Errorln(err)
+func (tx *memTx) Watch(ctx context.Context, keys ...string) error {
+	tx.memStore.rwMutex.RLock()
+	defer tx.memStore.rwMutex.RUnlock()
+
+	if err := tx.checkOpen(ctx); err != nil {
 		return err
 	}
 
+	root := tx.root()
+	root.rwLock.Lock()
+	defer root.rwLock.Unlock()
+
+	for _, key := range keys {
+		root.watchedKeys[key] = struct{}{}
+	}
+	return nil
+}
+
+func (tx *memTx) trackRead(key string) {
+	tx = tx.root()
+	if tx.isolation != Serializable {
+		return
+	}
+	tx.rwLock.Lock()
+	defer tx.rwLock.Unlock()
+	tx.watchedKeys[key] = struct{}{}
+}
+
+func (tx *memTx) trackReadRange(start, end string) {
+	tx = tx.root()
+	if tx.isolation != Serializable {
+		return
+	}
+	tx.rwLock.Lock()
+	defer tx.rwLock.Unlock()
+	tx.readRanges = append(tx.readRanges, keyRange{start: start, end: end})
+}
+
+// checkWatchedKeys must be called while holding the store write lock.
+func (tx *memTx) checkWatchedKeys(ctx context.Context) error {
+	tx.rwLock.RLock()
+	defer tx.rwLock.RUnlock()
+
+	for key := range tx.watchedKeys {
+		if err := tx.memStore.checkKeyNotCommittedAfter(ctx, key, tx.memStore.locks.readAt(tx.txID, key)); err != nil {
+			return err
+		}
+	}
+
+	// a key committed into a scanned range after our snapshot is a phantom,
+	// its first version is newer than txID just like an overwrite would be
+	for _, readRange := range tx.readRanges {
+		var err error
+		tx.memStore.keys.Ascend(readRange.start, readRange.end, func(key string, _ struct{}) bool {
+			err = tx.memStore.checkKeyNotCommittedAfter(ctx, key, tx.txID)
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
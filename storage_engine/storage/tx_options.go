@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"time"
+)
+
+type IsolationLevel int
+
+const (
+	// RepeatableRead (snapshot isolation) only rejects write-write conflicts,
+	// so write skew between concurrent transactions is possible.
+	RepeatableRead IsolationLevel = iota
+	// Serializable also validates at commit that nothing the transaction read,
+	// keys and scanned ranges alike, was overwritten after its snapshot.
+	Serializable
+)
+
+const (
+	// DefaultMaxAttempts is how often Update runs a transaction that keeps
+	// conflicting, unless WithMaxAttempts says otherwise.
+	DefaultMaxAttempts = 5
+	// DefaultBackoff is the wait before the first retry of Update, doubled for
+	// every further one.
+	DefaultBackoff = time.Millisecond
+)
+
+type txOptions struct {
+	isolation   IsolationLevel
+	readOnly    bool
+	maxAttempts int
+	backoff     time.Duration
+	timeout     time.Duration
+	ctx         context.Context
+	lockWait    *LockWaitPolicy
+	identity    string
+}
+
+type TxOption func(o *txOptions)
+
+func WithIsolation(level IsolationLevel) TxOption {
+	return func(o *txOptions) {
+		o.isolation = level
+	}
+}
+
+// ReadOnly makes Tx return the same read-only transaction as ReadTx.
+func ReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.readOnly = true
+	}
+}
+
+// WithTimeout expires the transaction timeout after it starts, instead of
+// appCommon.TransactionTimeout. A timeout <= 0 means none.
+func WithTimeout(timeout time.Duration) TxOption {
+	return func(o *txOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithContext expires the transaction as soon as ctx is done, or at its
+// deadline if that comes first.
+func WithContext(ctx context.Context) TxOption {
+	return func(o *txOptions) {
+		o.ctx = ctx
+	}
+}
+
+// deadline is when a transaction started at startedAt expires, zero if never.
+func (o txOptions) deadline(startedAt time.Time) time.Time {
+	var deadline time.Time
+	if o.timeout > 0 {
+		deadline = startedAt.Add(o.timeout)
+	}
+	if o.ctx != nil {
+		if ctxDeadline, ok := o.ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+			deadline = ctxDeadline
+		}
+	}
+	return deadline
+}
+
+// WithTxLockWait overrides the store's LockWaitPolicy for the Lock,
+// GetForUpdate and Commit calls of the transaction.
+func WithTxLockWait(policy LockWaitPolicy) TxOption {
+	return func(o *txOptions) {
+		o.lockWait = &policy
+	}
+}
+
+// WithIdentity names the logical transaction, the same for every retry of it.
+// The store counts the conflict aborts per identity, and once they reach the
+// starvation threshold the next transaction of that identity starts with the
+// keys its last conflicting attempt wrote locked, see Lock. Identities are
+// kept for the stats, so use a bounded set of them.
+func WithIdentity(identity string) TxOption {
+	return func(o *txOptions) {
+		o.identity = identity
+	}
+}
+
+// WithMaxAttempts bounds how often Update runs the transaction, the first
+// attempt included. It has no effect on Tx.
+func WithMaxAttempts(attempts int) TxOption {
+	return func(o *txOptions) {
+		o.maxAttempts = max(attempts, 1)
+	}
+}
+
+// WithBackoff sets the wait before the first retry of Update. It has no effect
+// on Tx.
+func WithBackoff(backoff time.Duration) TxOption {
+	return func(o *txOptions) {
+		o.backoff = backoff
+	}
+}
+
+func newTxOptions(opts []TxOption) txOptions {
+	options := txOptions{
+		isolation:   RepeatableRead,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		timeout:     appCommon.TransactionTimeout,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemTx_Timeout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("An expired transaction rejects operations", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.Tx(WithTimeout(10 * time.Millisecond))
+		assert.NoError(t, tx.Set(ctx, "a", 1))
+		time.Sleep(20 * time.Millisecond)
+
+		var expired *appCommon.TxExpiredError
+		assert.ErrorAs(t, tx.Set(ctx, "b", 2), &expired)
+		_, err := tx.Get(ctx, "a")
+		assert.ErrorAs(t, err, &expired)
+		assert.ErrorAs(t, tx.Commit(ctx), &expired)
+		assert.Equal(t, tx.ID(), expired.TxID)
+
+		assert.Empty(t, storage.Transactions(ctx))
+		_, err = storage.Get(ctx, "a")
+		assert.ErrorIs(t, err, appCommon.KeyDoesNotExist)
+	})
+
+	t.Run("Expired transactions are reaped", func(t *testing.T) {
+		storage := NewMemStore()
+		abandoned := storage.Tx(WithTimeout(10 * time.Millisecond))
+		kept := storage.Tx()
+		assert.NotZero(t, storage.Transactions(ctx)[1].Deadline)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.NoError(t, storage.RemoveExpiredTransactions(ctx))
+		transactions := storage.Transactions(ctx)
+		assert.Len(t, transactions, 1)
+		assert.Equal(t, kept.ID(), transactions[0].TxID)
+
+		var expired *appCommon.TxExpiredError
+		assert.ErrorAs(t, abandoned.Abort(ctx), &expired)
+		assert.NoError(t, kept.Abort(ctx))
+	})
+
+	t.Run("Expired read-only transactions are unpinned", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "a", 1))
+		leaked := storage.Tx(ReadOnly(), WithTimeout(10*time.Millisecond))
+		viewCtx, cancel := context.WithCancel(ctx)
+		bound := storage.Tx(ReadOnly(), WithContext(viewCtx))
+		assert.NoError(t, storage.Set(ctx, "a", 2))
+
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(t, storage.RemoveOldVersionTransaction(ctx))
+		history, err := storage.History(ctx, "a")
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+
+		var expired *appCommon.TxExpiredError
+		_, err = leaked.Get(ctx, "a")
+		assert.ErrorAs(t, err, &expired)
+		assert.ErrorAs(t, leaked.Abort(ctx), &expired)
+		assert.ErrorAs(t, bound.Commit(ctx), &expired)
+	})
+
+	t.Run("A transaction ends with its context", func(t *testing.T) {
+		storage := NewMemStore()
+		txCtx, cancel := context.WithCancel(ctx)
+		tx := storage.Tx(WithContext(txCtx), WithTimeout(0))
+		assert.NoError(t, tx.Set(ctx, "a", 1))
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			return len(storage.Transactions(ctx)) == 0
+		}, time.Second, time.Millisecond)
+		var expired *appCommon.TxExpiredError
+		assert.ErrorAs(t, tx.Commit(ctx), &expired)
+	})
+
+	t.Run("Operations honor their own context", func(t *testing.T) {
+		storage := NewMemStore()
+		tx := storage.Tx()
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		assert.ErrorIs(t, tx.Set(canceled, "a", 1), context.Canceled)
+		assert.ErrorIs(t, tx.Commit(canceled), context.Canceled)
+		// the transaction is still usable and can be aborted with a done context
+		assert.NoError(t, tx.Set(ctx, "a", 1))
+		assert.NoError(t, tx.Abort(canceled))
+
+		readTx := storage.ReadTx()
+		_, err := readTx.Get(canceled, "a")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, readTx.Abort(ctx))
+	})
+
+	t.Run("Update binds the transaction to its context", func(t *testing.T) {
+		storage := NewMemStore()
+		updateCtx, cancel := context.WithCancel(ctx)
+		err := storage.Update(updateCtx, func(tx MemTx) error {
+			cancel()
+			return tx.Set(ctx, "a", 1)
+		})
+		var expired *appCommon.TxExpiredError
+		assert.ErrorAs(t, err, &expired)
+		assert.Empty(t, storage.Transactions(ctx))
+	})
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/index"
+)
+
+func byEmail(value interface{}) []index.Tuple {
+	user, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return []index.Tuple{{user["email"]}}
+}
+
+func withEmail(email string) map[string]interface{} {
+	return map[string]interface{}{"email": email}
+}
+
+func TestMemStorage_UniqueIndex(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Auto-commit writes", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.CreateUniqueIndex(ctx, "email", byEmail))
+		assert.NoError(t, storage.Set(ctx, "u1", withEmail("a@x")))
+
+		var violation *appCommon.UniqueViolationError
+		assert.ErrorAs(t, storage.Set(ctx, "u2", withEmail("a@x")), &violation)
+		assert.Equal(t, "email", violation.Index)
+		assert.Equal(t, "u1", violation.ConflictingKey)
+
+		// rewriting the same key keeps its email
+		assert.NoError(t, storage.Set(ctx, "u1", withEmail("a@x")))
+		assert.NoError(t, storage.Delete(ctx, "u1"))
+		assert.NoError(t, storage.Set(ctx, "u2", withEmail("a@x")))
+	})
+
+	t.Run("Concurrent transactions", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.CreateUniqueIndex(ctx, "email", byEmail))
+
+		tx1 := storage.Tx()
+		tx2 := storage.Tx()
+		assert.NoError(t, tx1.Set(ctx, "u1", withEmail("a@x")))
+		assert.NoError(t, tx2.Set(ctx, "u2", withEmail("a@x")))
+
+		assert.NoError(t, tx1.Commit(ctx))
+		var violation *appCommon.UniqueViolationError
+		assert.ErrorAs(t, tx2.Commit(ctx), &violation)
+		assert.Equal(t, "u2", violation.Key)
+		assert.NoError(t, tx2.Abort(ctx))
+	})
+
+	t.Run("Within one transaction", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.CreateUniqueIndex(ctx, "email", byEmail))
+		assert.NoError(t, storage.Set(ctx, "u1", withEmail("a@x")))
+
+		swap := storage.Tx()
+		assert.NoError(t, swap.Set(ctx, "u1", withEmail("b@x")))
+		assert.NoError(t, swap.Set(ctx, "u2", withEmail("a@x")))
+		assert.NoError(t, swap.Commit(ctx))
+
+		clash := storage.Tx()
+		assert.NoError(t, clash.Set(ctx, "u3", withEmail("c@x")))
+		assert.NoError(t, clash.Set(ctx, "u4", withEmail("c@x")))
+		var violation *appCommon.UniqueViolationError
+		assert.ErrorAs(t, clash.Commit(ctx), &violation)
+	})
+
+	t.Run("Existing duplicates", func(t *testing.T) {
+		storage := NewMemStore()
+		assert.NoError(t, storage.Set(ctx, "u1", withEmail("a@x")))
+		assert.NoError(t, storage.Set(ctx, "u2", withEmail("a@x")))
+
+		var violation *appCommon.UniqueViolationError
+		assert.ErrorAs(t, storage.CreateUniqueIndex(ctx, "email", byEmail), &violation)
+		_, err := storage.LookupIndex(ctx, "email", index.Tuple{"a@x"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/wal"
+	"time"
+)
+
+func newSetEntry(key string, value interface{}, expireAt time.Time) wal.Entry {
+	return wal.Entry{
+		Key:           key,
+		OperationType: operation.SET,
+		Value:         value,
+		ExpireAt:      expireAt,
+	}
+}
+
+func newDeleteEntry(key string) wal.Entry {
+	return wal.Entry{
+		Key:           key,
+		OperationType: operation.DELETE,
+	}
+}
+
+// openWAL opens the log in s.walDir and replays every record newer than
+// afterTxID into the (still private) store, moving the clock past the last
+// logged transaction. The changelog starts at the first logged record: a log
+// cut by TruncateBefore no longer holds the commits before it. (A log whose
+// first commit was not the first txID looks cut as well, which only costs a
+// subscriber resuming from before it a reload.)
+func (s *memStore) openWAL(afterTxID int) error {
+	log, err := wal.Open(s.walDir, s.walOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	replayed := 0
+	first := true
+	err = log.Replay(func(record wal.Record) error {
+		if first {
+			s.changelog.startAfter(record.TxID - 1)
+			first = false
+		}
+		if record.TxID <= afterTxID {
+			return nil
+		}
+		s.clock.Observe(record.TxID)
+		s.applyEntries(ctx, record.TxID, record.Entries)
+		s.publish(record)
+		replayed++
+		return nil
+	})
+	if err != nil {
+		_ = log.Close()
+		return fmt.Errorf("cannot replay wal in %s: %w", s.walDir, err)
+	}
+
+	s.logger.Infof("Replayed %d wal records, clock restored to %d", replayed, s.clock.Current())
+	s.wal = log
+	return nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func TestMemStorage_WALRecovery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	options := wal.Options{SyncPolicy: wal.SyncEveryCommit}
+
+	store, err := OpenMemStore(WithWAL(dir, options))
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Set(ctx, "key1", "value1"))
+	assert.NoError(t, store.Set(ctx, "key2", "value2"))
+	assert.NoError(t, store.Delete(ctx, "key2"))
+
+	tx := store.Tx()
+	assert.NoError(t, tx.Set(ctx, "key3", map[string]interface{}{"name": "John"}))
+	assert.NoError(t, tx.Set(ctx, "key1", "txValue1"))
+	assert.NoError(t, tx.Commit(ctx))
+
+	aborted := store.Tx()
+	assert.NoError(t, aborted.Set(ctx, "key4", "abortedValue"))
+	assert.NoError(t, aborted.Abort(ctx))
+	assert.NoError(t, store.Close())
+
+	restored, err := OpenMemStore(WithWAL(dir, options))
+	assert.NoError(t, err)
+	defer restored.Close()
+
+	value, err := restored.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "txValue1", value)
+
+	value, _ = restored.Get(ctx, "key2")
+	assert.Nil(t, value)
+
+	value, err = restored.Get(ctx, "key3")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "John"}, value)
+
+	_, err = restored.Get(ctx, "key4")
+	assert.Equal(t, appCommon.KeyDoesNotExist, err)
+
+	// the restored clock must be ahead of every logged version, otherwise a new
+	// transaction could not see the replayed data
+	newTx := restored.Tx()
+	value, err = newTx.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "txValue1", value)
+	assert.NoError(t, newTx.Abort(ctx))
+}
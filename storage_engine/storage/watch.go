@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/wal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchEvent is the latest change of a watched key. TxID is the version that
+// wrote it, as in History.
+type WatchEvent struct {
+	Key      string
+	TxID     int
+	Deleted  bool
+	Value    interface{}
+	ExpireAt time.Time
+}
+
+// Watcher reports the changes of the keys it watches, made after it was
+// created. Changes are compacted per key: a watcher that is not read for a
+// while gets the latest value of each changed key once, never a backlog, and
+// never makes a commit wait.
+type Watcher interface {
+	// Next blocks until a watched key has changed since the last call, or ctx
+	// is done, or the watcher is closed.
+	Next(ctx context.Context) (WatchEvent, error)
+	Close()
+}
+
+// watchHub holds the open watchers. It has its own lock so notifying them
+// never waits on a reader.
+type watchHub struct {
+	mutex    sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watchers: make(map[*watcher]struct{})}
+}
+
+func (hub *watchHub) notify(record wal.Record) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	for w := range hub.watchers {
+		for _, entry := range record.Entries {
+			if !w.matches(entry.Key) {
+				continue
+			}
+			w.push(WatchEvent{
+				Key:      entry.Key,
+				TxID:     record.TxID,
+				Deleted:  entry.OperationType == operation.DELETE,
+				Value:    entry.Value,
+				ExpireAt: entry.ExpireAt,
+			})
+		}
+	}
+}
+
+func (hub *watchHub) remove(w *watcher) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	delete(hub.watchers, w)
+}
+
+type watcher struct {
+	hub    *watchHub
+	key    string
+	prefix bool
+
+	mutex   sync.Mutex
+	pending map[string]WatchEvent
+	order   []string      // pending keys, first changed first
+	ready   chan struct{} // holds a token while something is pending
+	closed  chan struct{}
+	once    sync.Once
+	stop    func() bool
+}
+
+func (w *watcher) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+	return key == w.key
+}
+
+// push replaces the pending event of the key, if any, so it never grows past
+// one event per key.
+func (w *watcher) push(event WatchEvent) {
+	w.mutex.Lock()
+	if _, ok := w.pending[event.Key]; !ok {
+		w.order = append(w.order, event.Key)
+	}
+	w.pending[event.Key] = event
+	w.mutex.Unlock()
+
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) pop() (WatchEvent, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.order) == 0 {
+		return WatchEvent{}, false
+	}
+	key := w.order[0]
+	w.order = w.order[1:]
+	event := w.pending[key]
+	delete(w.pending, key)
+	return event, true
+}
+
+// Watch watches key until ctx is done or the watcher is closed.
+func (s *memStore) Watch(ctx context.Context, key string) (Watcher, error) {
+	return s.watch(ctx, key, false)
+}
+
+// WatchPrefix watches every key starting with prefix.
+func (s *memStore) WatchPrefix(ctx context.Context, prefix string) (Watcher, error) {
+	return s.watch(ctx, prefix, true)
+}
+
+func (s *memStore) watch(ctx context.Context, key string, prefix bool) (Watcher, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		hub:     s.watchers,
+		key:     key,
+		prefix:  prefix,
+		pending: make(map[string]WatchEvent),
+		ready:   make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+	s.watchers.mutex.Lock()
+	s.watchers.watchers[w] = struct{}{}
+	s.watchers.mutex.Unlock()
+
+	// the callback does not read w.stop, which may not be set when it runs
+	w.stop = context.AfterFunc(ctx, w.close)
+	return w, nil
+}
+
+func (w *watcher) Next(ctx context.Context) (WatchEvent, error) {
+	for {
+		select {
+		case <-w.closed:
+			return WatchEvent{}, appCommon.WatcherClosed
+		default:
+		}
+		if event, ok := w.pop(); ok {
+			return event, nil
+		}
+
+		select {
+		case <-w.ready:
+		case <-w.closed:
+		case <-ctx.Done():
+			return WatchEvent{}, ctx.Err()
+		}
+	}
+}
+
+func (w *watcher) Close() {
+	w.stop()
+	w.close()
+}
+
+func (w *watcher) close() {
+	w.once.Do(func() {
+		w.hub.remove(w)
+		close(w.closed)
+	})
+}
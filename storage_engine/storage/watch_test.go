@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+)
+
+func TestMemStorage_Watch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Watch blocks until the key changes", func(t *testing.T) {
+		storage := NewMemStore()
+		watcher, err := storage.Watch(ctx, "config")
+		assert.NoError(t, err)
+		defer watcher.Close()
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = storage.Set(ctx, "other", 1)
+			_ = storage.Set(ctx, "config", "v1")
+		}()
+		event, err := watcher.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "config", event.Key)
+		assert.Equal(t, "v1", event.Value)
+
+		history, _ := storage.History(ctx, "config")
+		assert.Equal(t, history[len(history)-1].TxID, event.TxID)
+
+		assert.NoError(t, storage.Delete(ctx, "config"))
+		event, err = watcher.Next(ctx)
+		assert.NoError(t, err)
+		assert.True(t, event.Deleted)
+	})
+
+	t.Run("WatchPrefix sees transaction commits", func(t *testing.T) {
+		storage := NewMemStore()
+		watcher, err := storage.WatchPrefix(ctx, "app/")
+		assert.NoError(t, err)
+		defer watcher.Close()
+
+		tx := storage.Tx()
+		assert.NoError(t, tx.Set(ctx, "app/a", 1))
+		assert.NoError(t, tx.Set(ctx, "app/b", 2))
+		assert.NoError(t, tx.Set(ctx, "db/a", 3))
+		assert.NoError(t, tx.Commit(ctx))
+
+		first, _ := watcher.Next(ctx)
+		second, _ := watcher.Next(ctx)
+		assert.Equal(t, []string{"app/a", "app/b"}, []string{first.Key, second.Key})
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = watcher.Next(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("A slow watcher gets the latest value only", func(t *testing.T) {
+		storage := NewMemStore()
+		watcher, err := storage.Watch(ctx, "counter")
+		assert.NoError(t, err)
+		defer watcher.Close()
+
+		for i := 0; i < 1000; i++ {
+			assert.NoError(t, storage.Set(ctx, "counter", i))
+		}
+		event, err := watcher.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 999, event.Value)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = watcher.Next(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Closing the watcher or its context ends it", func(t *testing.T) {
+		storage := NewMemStore()
+		watcher, err := storage.Watch(ctx, "a")
+		assert.NoError(t, err)
+		watcher.Close()
+		_, err = watcher.Next(ctx)
+		assert.ErrorIs(t, err, appCommon.WatcherClosed)
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		watcher, err = storage.Watch(watchCtx, "a")
+		assert.NoError(t, err)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		_, err = watcher.Next(ctx)
+		assert.ErrorIs(t, err, appCommon.WatcherClosed)
+		assert.Empty(t, storage.(*memStore).watchers.watchers)
+	})
+
+	t.Run("A context done while Watch starts closes the watcher", func(t *testing.T) {
+		storage := NewMemStore()
+		for i := 0; i < 100; i++ {
+			watchCtx, cancel := context.WithCancel(ctx)
+			go cancel()
+			watcher, err := storage.Watch(watchCtx, "a")
+			if err != nil {
+				assert.ErrorIs(t, err, context.Canceled)
+				continue
+			}
+			_, err = watcher.Next(ctx)
+			assert.ErrorIs(t, err, appCommon.WatcherClosed)
+			watcher.Close()
+		}
+		assert.Empty(t, storage.(*memStore).watchers.watchers)
+	})
+}
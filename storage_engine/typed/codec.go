@@ -0,0 +1,122 @@
+package typed
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec converts between V and the value kept in the store.
+type Codec[V any] interface {
+	Encode(value V) (interface{}, error)
+	Decode(raw interface{}) (V, error)
+}
+
+type rawCodec[V any] struct{}
+
+// RawCodec stores values as they are and only type-asserts them on the way
+// out. It is the cheapest codec but values are shared with the store, and
+// non-basic types need wal.Register to be written to the WAL.
+func RawCodec[V any]() Codec[V] {
+	return rawCodec[V]{}
+}
+
+func (rawCodec[V]) Encode(value V) (interface{}, error) {
+	return value, nil
+}
+
+func (rawCodec[V]) Decode(raw interface{}) (V, error) {
+	value, ok := raw.(V)
+	if !ok {
+		var zero V
+		return zero, fmt.Errorf("stored value is %T, not %T", raw, zero)
+	}
+	return value, nil
+}
+
+type jsonCodec[V any] struct{}
+
+// JSONCodec stores values as JSON encoded []byte.
+func JSONCodec[V any]() Codec[V] {
+	return jsonCodec[V]{}
+}
+
+func (jsonCodec[V]) Encode(value V) (interface{}, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec[V]) Decode(raw interface{}) (V, error) {
+	var value V
+	data, err := rawBytes(raw)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+type gobCodec[V any] struct{}
+
+// GobCodec stores values as gob encoded []byte.
+func GobCodec[V any]() Codec[V] {
+	return gobCodec[V]{}
+}
+
+func (gobCodec[V]) Encode(value V) (interface{}, error) {
+	buffer := new(bytes.Buffer)
+	if err := gob.NewEncoder(buffer).Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (gobCodec[V]) Decode(raw interface{}) (V, error) {
+	var value V
+	data, err := rawBytes(raw)
+	if err != nil {
+		return value, err
+	}
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// BinaryMessage is implemented by pointers to types with their own binary
+// encoding, such as generated protobuf-like messages.
+type BinaryMessage[V any] interface {
+	*V
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+type binaryCodec[V any, P BinaryMessage[V]] struct{}
+
+// BinaryCodec stores values as the []byte produced by their MarshalBinary.
+func BinaryCodec[V any, P BinaryMessage[V]]() Codec[V] {
+	return binaryCodec[V, P]{}
+}
+
+func (binaryCodec[V, P]) Encode(value V) (interface{}, error) {
+	return P(&value).MarshalBinary()
+}
+
+func (binaryCodec[V, P]) Decode(raw interface{}) (V, error) {
+	var value V
+	data, err := rawBytes(raw)
+	if err != nil {
+		return value, err
+	}
+	err = P(&value).UnmarshalBinary(data)
+	return value, err
+}
+
+func rawBytes(raw interface{}) ([]byte, error) {
+	switch data := raw.(type) {
+	case []byte:
+		return data, nil
+	case string:
+		return []byte(data), nil
+	}
+	return nil, fmt.Errorf("stored value is %T, not encoded bytes", raw)
+}
@@ -0,0 +1,130 @@
+package typed
+
+import (
+	"context"
+	"fmt"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/storage"
+	"time"
+)
+
+type KeyValue[V any] struct {
+	Key   string
+	Value V
+}
+
+// Store is a MemStorage holding values of type V. Absent keys read as
+// appCommon.KeyDoesNotExist and values the codec cannot read back as
+// *appCommon.DecodeError.
+type Store[V any] interface {
+	Get(ctx context.Context, key string) (V, error)
+	Set(ctx context.Context, key string, value V) error
+	SetWithTTL(ctx context.Context, key string, value V, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Scan(ctx context.Context, start, end string, limit int) ([]KeyValue[V], error)
+	ScanPrefix(ctx context.Context, prefix string) ([]KeyValue[V], error)
+	Tx(opts ...storage.TxOption) Tx[V]
+	ReadTx() Tx[V]
+	// Untyped returns the wrapped store.
+	Untyped() storage.MemStorage
+}
+
+type typedStore[V any] struct {
+	store storage.MemStorage
+	codec Codec[V]
+}
+
+func NewStore[V any](store storage.MemStorage, codec Codec[V]) Store[V] {
+	return &typedStore[V]{store: store, codec: codec}
+}
+
+func (s *typedStore[V]) Get(ctx context.Context, key string) (V, error) {
+	raw, err := s.store.Get(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return decode(s.codec, key, raw)
+}
+
+func (s *typedStore[V]) Set(ctx context.Context, key string, value V) error {
+	raw, err := encode(s.codec, key, value)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, key, raw)
+}
+
+func (s *typedStore[V]) SetWithTTL(ctx context.Context, key string, value V, ttl time.Duration) error {
+	raw, err := encode(s.codec, key, value)
+	if err != nil {
+		return err
+	}
+	return s.store.SetWithTTL(ctx, key, raw, ttl)
+}
+
+func (s *typedStore[V]) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, key)
+}
+
+func (s *typedStore[V]) Scan(ctx context.Context, start, end string, limit int) ([]KeyValue[V], error) {
+	raw, err := s.store.Scan(ctx, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(s.codec, raw)
+}
+
+func (s *typedStore[V]) ScanPrefix(ctx context.Context, prefix string) ([]KeyValue[V], error) {
+	raw, err := s.store.ScanPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(s.codec, raw)
+}
+
+func (s *typedStore[V]) Tx(opts ...storage.TxOption) Tx[V] {
+	return WrapTx(s.store.Tx(opts...), s.codec)
+}
+
+func (s *typedStore[V]) ReadTx() Tx[V] {
+	return WrapTx(s.store.ReadTx(), s.codec)
+}
+
+func (s *typedStore[V]) Untyped() storage.MemStorage {
+	return s.store
+}
+
+// decode turns a stored value back into V, a nil one (deleted or expired)
+// reads as a missing key.
+func decode[V any](codec Codec[V], key string, raw interface{}) (V, error) {
+	if raw == nil {
+		var zero V
+		return zero, appCommon.KeyDoesNotExist
+	}
+	value, err := codec.Decode(raw)
+	if err != nil {
+		return value, appCommon.NewDecodeError(key, err)
+	}
+	return value, nil
+}
+
+func decodeAll[V any](codec Codec[V], raw []storage.KeyValue) ([]KeyValue[V], error) {
+	values := make([]KeyValue[V], 0, len(raw))
+	for _, kv := range raw {
+		value, err := decode(codec, kv.Key, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, KeyValue[V]{Key: kv.Key, Value: value})
+	}
+	return values, nil
+}
+
+func encode[V any](codec Codec[V], key string, value V) (interface{}, error) {
+	raw, err := codec.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode value of key %q: %w", key, err)
+	}
+	return raw, nil
+}
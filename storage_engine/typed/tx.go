@@ -0,0 +1,99 @@
+package typed
+
+import (
+	"context"
+	"in-memory-storage-engine/storage_engine/storage"
+	"time"
+)
+
+// Tx is a MemTx holding values of type V, with the same error rules as Store.
+type Tx[V any] interface {
+	ID() int
+	Get(ctx context.Context, key string) (V, error)
+	Set(ctx context.Context, key string, value V) error
+	SetWithTTL(ctx context.Context, key string, value V, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Scan(ctx context.Context, start, end string, limit int) ([]KeyValue[V], error)
+	ScanPrefix(ctx context.Context, prefix string) ([]KeyValue[V], error)
+	Watch(ctx context.Context, keys ...string) error
+	Commit(ctx context.Context) error
+	Abort(ctx context.Context) error
+	// Untyped returns the wrapped transaction.
+	Untyped() storage.MemTx
+}
+
+type typedTx[V any] struct {
+	tx    storage.MemTx
+	codec Codec[V]
+}
+
+// WrapTx gives an existing transaction a typed view, e.g. to mix value types
+// in one transaction.
+func WrapTx[V any](tx storage.MemTx, codec Codec[V]) Tx[V] {
+	return &typedTx[V]{tx: tx, codec: codec}
+}
+
+func (tx *typedTx[V]) ID() int {
+	return tx.tx.ID()
+}
+
+func (tx *typedTx[V]) Get(ctx context.Context, key string) (V, error) {
+	raw, err := tx.tx.Get(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return decode(tx.codec, key, raw)
+}
+
+func (tx *typedTx[V]) Set(ctx context.Context, key string, value V) error {
+	raw, err := encode(tx.codec, key, value)
+	if err != nil {
+		return err
+	}
+	return tx.tx.Set(ctx, key, raw)
+}
+
+func (tx *typedTx[V]) SetWithTTL(ctx context.Context, key string, value V, ttl time.Duration) error {
+	raw, err := encode(tx.codec, key, value)
+	if err != nil {
+		return err
+	}
+	return tx.tx.SetWithTTL(ctx, key, raw, ttl)
+}
+
+func (tx *typedTx[V]) Delete(ctx context.Context, key string) error {
+	return tx.tx.Delete(ctx, key)
+}
+
+func (tx *typedTx[V]) Scan(ctx context.Context, start, end string, limit int) ([]KeyValue[V], error) {
+	raw, err := tx.tx.Scan(ctx, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(tx.codec, raw)
+}
+
+func (tx *typedTx[V]) ScanPrefix(ctx context.Context, prefix string) ([]KeyValue[V], error) {
+	raw, err := tx.tx.ScanPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll(tx.codec, raw)
+}
+
+func (tx *typedTx[V]) Watch(ctx context.Context, keys ...string) error {
+	return tx.tx.Watch(ctx, keys...)
+}
+
+func (tx *typedTx[V]) Commit(ctx context.Context) error {
+	return tx.tx.Commit(ctx)
+}
+
+func (tx *typedTx[V]) Abort(ctx context.Context) error {
+	return tx.tx.Abort(ctx)
+}
+
+func (tx *typedTx[V]) Untyped() storage.MemTx {
+	return tx.tx
+}
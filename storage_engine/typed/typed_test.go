@@ -0,0 +1,108 @@
+package typed
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/appCommon"
+	"in-memory-storage-engine/storage_engine/storage"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+// point has its own binary encoding, like a generated message would.
+type point struct {
+	X, Y int32
+}
+
+func (p *point) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data, uint32(p.X))
+	binary.BigEndian.PutUint32(data[4:], uint32(p.Y))
+	return data, nil
+}
+
+func (p *point) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("point needs 8 bytes")
+	}
+	p.X = int32(binary.BigEndian.Uint32(data))
+	p.Y = int32(binary.BigEndian.Uint32(data[4:]))
+	return nil
+}
+
+func TestStore_Codecs(t *testing.T) {
+	ctx := context.Background()
+	codecs := map[string]Codec[user]{
+		"raw":  RawCodec[user](),
+		"json": JSONCodec[user](),
+		"gob":  GobCodec[user](),
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			users := NewStore(storage.NewMemStore(), codec)
+			assert.NoError(t, users.Set(ctx, "user:1", user{Name: "John", Age: 30}))
+
+			value, err := users.Get(ctx, "user:1")
+			assert.NoError(t, err)
+			assert.Equal(t, user{Name: "John", Age: 30}, value)
+
+			_, err = users.Get(ctx, "user:2")
+			assert.Equal(t, appCommon.KeyDoesNotExist, err)
+		})
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		points := NewStore(storage.NewMemStore(), BinaryCodec[point]())
+		assert.NoError(t, points.Set(ctx, "p", point{X: 1, Y: -2}))
+
+		value, err := points.Get(ctx, "p")
+		assert.NoError(t, err)
+		assert.Equal(t, point{X: 1, Y: -2}, value)
+	})
+}
+
+func TestStore_DecodeError(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemStore()
+	assert.NoError(t, store.Set(ctx, "user:1", "not a user"))
+
+	users := NewStore(store, RawCodec[user]())
+	_, err := users.Get(ctx, "user:1")
+
+	var decodeErr *appCommon.DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "user:1", decodeErr.Key)
+
+	_, err = NewStore(store, JSONCodec[user]()).ScanPrefix(ctx, "user:")
+	assert.ErrorAs(t, err, &decodeErr)
+}
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+	users := NewStore(storage.NewMemStore(), JSONCodec[user]())
+	assert.NoError(t, users.Set(ctx, "user:1", user{Name: "John", Age: 30}))
+
+	tx := users.Tx()
+	john, err := tx.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	john.Age++
+	assert.NoError(t, tx.Set(ctx, "user:1", john))
+	assert.NoError(t, tx.Set(ctx, "user:2", user{Name: "Jane", Age: 25}))
+	assert.NoError(t, tx.Delete(ctx, "user:2"))
+
+	_, err = tx.Get(ctx, "user:2")
+	assert.Equal(t, appCommon.KeyDoesNotExist, err)
+	assert.NoError(t, tx.Commit(ctx))
+
+	all, err := users.ScanPrefix(ctx, "user:")
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyValue[user]{{Key: "user:1", Value: user{Name: "John", Age: 31}}}, all)
+}
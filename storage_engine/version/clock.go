@@ -0,0 +1,42 @@
+package version
+
+import "sync/atomic"
+
+// Clock hands out monotonically increasing transaction ids. Each store owns its
+// own clock so that several stores can live in the same process without
+// sharing (and corrupting) each other's txIDs.
+type Clock interface {
+	Next() int
+	Current() int
+	// Observe moves the clock forward to txID if it is behind, used when state
+	// is restored from disk.
+	Observe(txID int)
+}
+
+type atomicClock struct {
+	counter *atomic.Int64
+}
+
+// NewClock returns a clock whose first Next() call returns start + 1.
+func NewClock(start int) Clock {
+	counter := new(atomic.Int64)
+	counter.Store(int64(start))
+	return &atomicClock{counter: counter}
+}
+
+func (c *atomicClock) Next() int {
+	return int(c.counter.Add(1))
+}
+
+func (c *atomicClock) Current() int {
+	return int(c.counter.Load())
+}
+
+func (c *atomicClock) Observe(txID int) {
+	for {
+		current := c.counter.Load()
+		if current >= int64(txID) || c.counter.CompareAndSwap(current, int64(txID)) {
+			return
+		}
+	}
+}
@@ -8,12 +8,22 @@ import (
 )
 
 type VersionManager interface {
-	Set(ctx context.Context, value interface{}, txID int)
+	// Set adds a version expiring at expireAt, a zero expireAt never expires.
+	Set(ctx context.Context, value interface{}, txID int, expireAt time.Time)
 	Delete(ctx context.Context, txID int) error
 	GetCommitted(ctx context.Context) interface{}
-	GetValueBeforeTransaction(ctx context.Context, txID int) interface{}
+	// GetValueBeforeTransaction and GetVersionBeforeTransaction read the
+	// snapshot of txID, taken at time at, so values expired by then are absent.
+	GetValueBeforeTransaction(ctx context.Context, txID int, at time.Time) interface{}
+	GetVersionBeforeTransaction(ctx context.Context, txID int, at time.Time) (VersionInfo, bool)
 	GetLatestVersionForKey(ctx context.Context) (int, error)
-	RemoveOldVersion(ctx context.Context) error
+	// RemoveOldVersion drops the versions no snapshot at or after watermark
+	// can read and reports whether none is left.
+	RemoveOldVersion(ctx context.Context, watermark int) (bool, error)
+	// RetainVersions drops every version but the latest one and those read by
+	// one of snapshots.
+	RetainVersions(ctx context.Context, snapshots []int)
+	History(ctx context.Context) []VersionInfo
 }
 
 type versionManager struct {
@@ -32,10 +42,10 @@ func (manager *versionManager) AddNewVersion(version *valueVersion) {
 	manager.versions = append(manager.versions, version)
 }
 
-func (manager *versionManager) Set(ctx context.Context, value interface{}, txID int) {
+func (manager *versionManager) Set(ctx context.Context, value interface{}, txID int, expireAt time.Time) {
 	manager.rwMutex.Lock()
 	defer manager.rwMutex.Unlock()
-	manager.AddNewVersion(newSetValueVersion(value, txID))
+	manager.AddNewVersion(newSetValueVersion(value, txID, expireAt))
 }
 
 func (manager *versionManager) getCommitedInternal(ctx context.Context) interface{} {
@@ -58,20 +68,29 @@ func (manager *versionManager) Delete(ctx context.Context, txID int) error {
 	return nil
 }
 
+// GetCommitted returns the latest committed value, nil if it is deleted or
+// expired by now.
 func (manager *versionManager) GetCommitted(ctx context.Context) interface{} {
 	manager.rwMutex.RLock()
 	defer manager.rwMutex.RUnlock()
 
-	return manager.getCommitedInternal(ctx)
+	if len(manager.versions) == 0 {
+		return nil
+	}
+	latest := manager.versions[len(manager.versions)-1]
+	if !latest.visibleAt(time.Now()) {
+		return nil
+	}
+	return latest.value
 }
 
-func (manager *versionManager) GetValueBeforeTransaction(ctx context.Context, txID int) interface{} {
+func (manager *versionManager) GetValueBeforeTransaction(ctx context.Context, txID int, at time.Time) interface{} {
 	manager.rwMutex.RLock()
 	defer manager.rwMutex.RUnlock()
 
 	for i := len(manager.versions) - 1; i >= 0; i-- {
 		if manager.versions[i].txID <= txID {
-			if manager.versions[i].isVisible {
+			if manager.versions[i].visibleAt(at) {
 				return manager.versions[i].value
 			} else {
 				return nil
@@ -81,6 +100,23 @@ func (manager *versionManager) GetValueBeforeTransaction(ctx context.Context, tx
 	return nil
 }
 
+// GetVersionBeforeTransaction returns the version visible to txID at time at.
+// The last result is false if the key has no visible value there.
+func (manager *versionManager) GetVersionBeforeTransaction(ctx context.Context, txID int, at time.Time) (VersionInfo, bool) {
+	manager.rwMutex.RLock()
+	defer manager.rwMutex.RUnlock()
+
+	for i := len(manager.versions) - 1; i >= 0; i-- {
+		if manager.versions[i].txID <= txID {
+			if manager.versions[i].visibleAt(at) {
+				return manager.versions[i].info(), true
+			}
+			return VersionInfo{}, false
+		}
+	}
+	return VersionInfo{}, false
+}
+
 func (manager *versionManager) GetLatestVersionForKey(ctx context.Context) (int, error) {
 	manager.rwMutex.RLock()
 	defer manager.rwMutex.RUnlock()
@@ -91,16 +127,58 @@ func (manager *versionManager) GetLatestVersionForKey(ctx context.Context) (int,
 	return manager.versions[len(manager.versions)-1].txID, nil
 }
 
-func (manager *versionManager) RemoveOldVersion(ctx context.Context) error {
+func (manager *versionManager) RemoveOldVersion(ctx context.Context, watermark int) (bool, error) {
+	manager.rwMutex.Lock()
+	defer manager.rwMutex.Unlock()
+
+	// the newest version at or below watermark is what the oldest snapshot
+	// reads, everything before it is unreachable
+	oldest := -1
+	for i := len(manager.versions) - 1; i >= 0; i-- {
+		if manager.versions[i].txID <= watermark {
+			oldest = i
+			break
+		}
+	}
+	if oldest == -1 {
+		return len(manager.versions) == 0, nil
+	}
+
+	// a tombstone reads the same as no version at all
+	if !manager.versions[oldest].isVisible {
+		oldest++
+	}
+	manager.versions = append(valueVersions{}, manager.versions[oldest:]...)
+	return len(manager.versions) == 0, nil
+}
+
+func (manager *versionManager) RetainVersions(ctx context.Context, snapshots []int) {
 	manager.rwMutex.Lock()
 	defer manager.rwMutex.Unlock()
 
-	current := time.Now()
-	for i := range manager.versions {
-		if current.Sub(manager.versions[i].createdAt) < appCommon.TransactionTimeout {
-			manager.versions = manager.versions[i:]
+	history := make([]VersionInfo, len(manager.versions))
+	for i, version := range manager.versions {
+		history[i] = version.info()
+	}
+	read := ReadBySnapshots(history, snapshots)
+
+	kept := make(valueVersions, 0, len(manager.versions))
+	for i, version := range manager.versions {
+		if read[i] || i == len(manager.versions)-1 {
+			kept = append(kept, version)
 		}
 	}
+	manager.versions = kept
+}
 
-	return nil
+// History returns every version still kept for the key, oldest first.
+func (manager *versionManager) History(ctx context.Context) []VersionInfo {
+	manager.rwMutex.RLock()
+	defer manager.rwMutex.RUnlock()
+
+	history := make([]VersionInfo, len(manager.versions))
+	for i, version := range manager.versions {
+		history[i] = version.info()
+	}
+	return history
 }
@@ -7,16 +7,18 @@ type valueVersion struct {
 	txID      int
 	isVisible bool
 	createdAt time.Time
+	expireAt  time.Time // zero means the value never expires
 }
 
 type valueVersions []*valueVersion
 
-func newSetValueVersion(value interface{}, txID int) *valueVersion {
+func newSetValueVersion(value interface{}, txID int, expireAt time.Time) *valueVersion {
 	return &valueVersion{
 		value:     value,
 		txID:      txID,
 		isVisible: true,
 		createdAt: time.Now(),
+		expireAt:  expireAt,
 	}
 }
 
@@ -27,3 +29,43 @@ func newDeleteValueVersion(txID int) *valueVersion {
 		createdAt: time.Now(),
 	}
 }
+
+// VersionInfo is the exported view of one committed version of a key.
+type VersionInfo struct {
+	TxID      int
+	Value     interface{}
+	Visible   bool
+	CreatedAt time.Time
+	ExpireAt  time.Time
+}
+
+func (version *valueVersion) info() VersionInfo {
+	return VersionInfo{
+		TxID:      version.txID,
+		Value:     version.value,
+		Visible:   version.isVisible,
+		CreatedAt: version.createdAt,
+		ExpireAt:  version.expireAt,
+	}
+}
+
+// ReadBySnapshots reports for each version of history, oldest first, whether
+// a snapshot of snapshots reads it: it is the newest version at or below one
+// of them.
+func ReadBySnapshots(history []VersionInfo, snapshots []int) []bool {
+	read := make([]bool, len(history))
+	for _, snapshot := range snapshots {
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].TxID <= snapshot {
+				read[i] = true
+				break
+			}
+		}
+	}
+	return read
+}
+
+// visibleAt reports whether the version holds a value that has not expired at.
+func (version *valueVersion) visibleAt(at time.Time) bool {
+	return version.isVisible && (version.expireAt.IsZero() || at.Before(version.expireAt))
+}
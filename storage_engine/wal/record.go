@@ -0,0 +1,50 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// Entry is a single key mutation of a committed write set. OperationType holds
+// one of the operation package constants (operation.SET or operation.DELETE).
+type Entry struct {
+	Key           string
+	OperationType int
+	Value         interface{}
+	ExpireAt      time.Time // zero unless the value was set with a TTL
+}
+
+// Record is everything one commit wrote, stamped with the txID it was applied
+// under.
+type Record struct {
+	TxID        int
+	Entries     []Entry
+	CommittedAt time.Time
+}
+
+// Register records the concrete type of values that are stored behind
+// interface{} so they can be written to (and read back from) the log. Basic
+// types such as string, int, float64 and bool are registered already.
+func Register(value interface{}) {
+	gob.Register(value)
+}
+
+func init() {
+	Register(map[string]interface{}{})
+	Register([]interface{}{})
+}
+
+func encodeRecord(record Record) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if err := gob.NewEncoder(buffer).Encode(record); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeRecord(payload []byte) (Record, error) {
+	var record Record
+	err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&record)
+	return record, err
+}
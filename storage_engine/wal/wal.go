@@ -0,0 +1,318 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type SyncPolicy int
+
+const (
+	// SyncEveryCommit fsyncs the log before a commit returns.
+	SyncEveryCommit SyncPolicy = iota
+	// SyncBatched fsyncs the log every Options.SyncInterval if anything was written.
+	SyncBatched
+	// SyncNone leaves flushing to the operating system.
+	SyncNone
+)
+
+const (
+	fileName            = "wal.log"
+	headerSize          = 8
+	DefaultSyncInterval = 100 * time.Millisecond
+	// MaxRecordSize bounds the encoded size of one record, so a corrupt frame
+	// header cannot make replay allocate gigabytes.
+	MaxRecordSize = 64 << 20
+)
+
+type Options struct {
+	SyncPolicy   SyncPolicy
+	SyncInterval time.Duration
+}
+
+// Log is an append-only file of committed write sets. Every record is framed as
+// <payload length><crc32 of payload><payload> so a torn tail left by a crash
+// can be detected and dropped on replay.
+type Log interface {
+	Append(record Record) error
+	Replay(fn func(record Record) error) error
+	// TruncateBefore drops every record with TxID <= txID, typically once a
+	// snapshot covering them has been written.
+	TruncateBefore(txID int) error
+	Close() error
+}
+
+type fileLog struct {
+	dir     string
+	mutex   *sync.Mutex
+	file    *os.File
+	options Options
+	dirty   bool
+	done    chan struct{}
+	wg      *sync.WaitGroup
+}
+
+func Open(dir string, options Options) (Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create wal directory %s: %w", dir, err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, fileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open wal file: %w", err)
+	}
+
+	if options.SyncInterval <= 0 {
+		options.SyncInterval = DefaultSyncInterval
+	}
+
+	log := &fileLog{
+		dir:     dir,
+		mutex:   new(sync.Mutex),
+		file:    file,
+		options: options,
+		done:    make(chan struct{}),
+		wg:      new(sync.WaitGroup),
+	}
+
+	if options.SyncPolicy == SyncBatched {
+		log.wg.Add(1)
+		go log.syncLoop()
+	}
+	return log, nil
+}
+
+func (l *fileLog) Append(record Record) error {
+	payload, err := encodeRecord(record)
+	if err != nil {
+		return fmt.Errorf("cannot encode wal record of transaction %d: %w", record.TxID, err)
+	}
+
+	if len(payload) > MaxRecordSize {
+		return fmt.Errorf("wal record of transaction %d is %d bytes, over the %d bytes limit", record.TxID, len(payload), MaxRecordSize)
+	}
+
+	frame := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[headerSize:], payload)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	offset, err := l.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	// a failed append must not leave a torn frame for later appends to follow
+	if _, err := l.file.Write(frame); err != nil {
+		return errors.Join(fmt.Errorf("cannot append wal record of transaction %d: %w", record.TxID, err), l.rewind(offset))
+	}
+
+	if l.options.SyncPolicy == SyncEveryCommit {
+		if err := l.file.Sync(); err != nil {
+			return errors.Join(fmt.Errorf("cannot sync wal record of transaction %d: %w", record.TxID, err), l.rewind(offset))
+		}
+		return nil
+	}
+	l.dirty = true
+	return nil
+}
+
+// rewind drops everything from offset on, the frame of a failed append.
+func (l *fileLog) rewind(offset int64) error {
+	if err := l.file.Truncate(offset); err != nil {
+		return fmt.Errorf("cannot rewind wal to offset %d: %w", offset, err)
+	}
+	return nil
+}
+
+// Replay calls fn for every record in the log, oldest first. A partially
+// written record at the end of the file is treated as never committed and is
+// cut off so that later appends start from a clean frame boundary. A bad record
+// followed by valid ones is corruption and fails the replay instead.
+func (l *fileLog) Replay(fn func(record Record) error) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.replay(func(record Record, _ []byte) error {
+		return fn(record)
+	})
+}
+
+func (l *fileLog) replay(fn func(record Record, frame []byte) error) error {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(l.file)
+	header := make([]byte, headerSize)
+	var offset int64
+
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return l.dropTornTail(offset)
+		}
+
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > MaxRecordSize {
+			return l.dropTornTail(offset)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return l.dropTornTail(offset)
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			return l.dropTornTail(offset)
+		}
+
+		record, err := decodeRecord(payload)
+		if err != nil {
+			return fmt.Errorf("cannot decode wal record at offset %d: %w", offset, err)
+		}
+		if err := fn(record, append(append([]byte{}, header...), payload...)); err != nil {
+			return err
+		}
+		offset += int64(headerSize + len(payload))
+	}
+}
+
+func (l *fileLog) TruncateBefore(txID int) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	path := filepath.Join(l.dir, fileName)
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot create temporary wal file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	err = l.replay(func(record Record, frame []byte) error {
+		if record.TxID <= txID {
+			return nil
+		}
+		_, err := writer.Write(frame)
+		return err
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot truncate wal before transaction %d: %w", txID, err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot replace wal file: %w", err)
+	}
+	_ = l.file.Close()
+	l.file = tmp
+	l.dirty = false
+	return SyncDir(l.dir)
+}
+
+// SyncDir flushes the entries of dir to disk, so a file renamed into it
+// survives a crash.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("cannot open directory %s: %w", dir, err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("cannot sync directory %s: %w", dir, err)
+	}
+	return nil
+}
+
+// dropTornTail cuts the log at offset, where a frame fails to read, if no valid
+// frame follows: that is a write torn by a crash. Otherwise the log is corrupt
+// in the middle, and cutting it would lose acknowledged commits.
+func (l *fileLog) dropTornTail(offset int64) error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	rest := make([]byte, info.Size()-offset)
+	if _, err := l.file.ReadAt(rest, offset); err != nil {
+		return fmt.Errorf("cannot read wal after offset %d: %w", offset, err)
+	}
+	for start := 1; start+headerSize <= len(rest); start++ {
+		if validFrame(rest[start:]) {
+			return fmt.Errorf("wal is corrupt at offset %d, valid records follow at offset %d", offset, offset+int64(start))
+		}
+	}
+
+	if err := l.file.Truncate(offset); err != nil {
+		return fmt.Errorf("cannot drop torn wal tail at offset %d: %w", offset, err)
+	}
+	return nil
+}
+
+// validFrame reports whether data starts with a complete, intact record.
+func validFrame(data []byte) bool {
+	size := binary.LittleEndian.Uint32(data[0:4])
+	if size > MaxRecordSize || int(size) > len(data)-headerSize {
+		return false
+	}
+	payload := data[headerSize : headerSize+int(size)]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[4:8]) {
+		return false
+	}
+	_, err := decodeRecord(payload)
+	return err == nil
+}
+
+func (l *fileLog) syncLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.options.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.mutex.Lock()
+			if l.dirty {
+				_ = l.file.Sync()
+				l.dirty = false
+			}
+			l.mutex.Unlock()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+func (l *fileLog) Close() error {
+	close(l.done)
+	l.wg.Wait()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.options.SyncPolicy != SyncNone {
+		if err := l.file.Sync(); err != nil {
+			return err
+		}
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,145 @@
+package wal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"in-memory-storage-engine/storage_engine/operation"
+	"in-memory-storage-engine/storage_engine/wal"
+)
+
+func replayAll(t *testing.T, log wal.Log) []wal.Record {
+	var records []wal.Record
+	err := log.Replay(func(record wal.Record) error {
+		records = append(records, record)
+		return nil
+	})
+	assert.NoError(t, err)
+	return records
+}
+
+func TestLog_AppendReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	policies := []struct {
+		name   string
+		policy wal.SyncPolicy
+	}{
+		{"sync every commit", wal.SyncEveryCommit},
+		{"sync batched", wal.SyncBatched},
+		{"sync none", wal.SyncNone},
+	}
+
+	for _, tt := range policies {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(dir, tt.name)
+			log, err := wal.Open(dir, wal.Options{SyncPolicy: tt.policy})
+			assert.NoError(t, err)
+
+			assert.NoError(t, log.Append(wal.Record{TxID: 1, Entries: []wal.Entry{
+				{Key: "key1", OperationType: operation.SET, Value: "value1"},
+				{Key: "key2", OperationType: operation.SET, Value: 2},
+			}}))
+			assert.NoError(t, log.Append(wal.Record{TxID: 3, Entries: []wal.Entry{
+				{Key: "key1", OperationType: operation.DELETE},
+			}}))
+			assert.NoError(t, log.Close())
+
+			log, err = wal.Open(dir, wal.Options{SyncPolicy: tt.policy})
+			assert.NoError(t, err)
+			defer log.Close()
+
+			records := replayAll(t, log)
+			assert.Len(t, records, 2)
+			assert.Equal(t, 1, records[0].TxID)
+			assert.Equal(t, "value1", records[0].Entries[0].Value)
+			assert.Equal(t, 2, records[0].Entries[1].Value)
+			assert.Equal(t, 3, records[1].TxID)
+			assert.Equal(t, operation.DELETE, records[1].Entries[0].OperationType)
+		})
+	}
+}
+
+func TestLog_TornTailIsDropped(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	assert.NoError(t, log.Append(wal.Record{TxID: 1, Entries: []wal.Entry{{Key: "key1", OperationType: operation.SET, Value: "value1"}}}))
+	assert.NoError(t, log.Append(wal.Record{TxID: 2, Entries: []wal.Entry{{Key: "key2", OperationType: operation.SET, Value: "value2"}}}))
+	assert.NoError(t, log.Close())
+
+	// simulate a crash in the middle of writing the second record
+	path := filepath.Join(dir, "wal.log")
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-3))
+
+	log, err = wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	records := replayAll(t, log)
+	assert.Len(t, records, 1)
+	assert.Equal(t, 1, records[0].TxID)
+
+	// appends after a torn tail must be readable again
+	assert.NoError(t, log.Append(wal.Record{TxID: 5, Entries: []wal.Entry{{Key: "key3", OperationType: operation.SET, Value: "value3"}}}))
+	assert.NoError(t, log.Close())
+
+	log, err = wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	defer log.Close()
+	records = replayAll(t, log)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 5, records[1].TxID)
+}
+
+func TestLog_CorruptRecordInTheMiddle(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	for txID := 1; txID <= 3; txID++ {
+		assert.NoError(t, log.Append(wal.Record{TxID: txID, Entries: []wal.Entry{{Key: "key", OperationType: operation.SET, Value: txID}}}))
+	}
+	assert.NoError(t, log.Close())
+
+	// flip a payload byte of the first record, the later ones are intact
+	path := filepath.Join(dir, "wal.log")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	data[10] ^= 0xFF
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+
+	log, err = wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	defer log.Close()
+	assert.Error(t, log.Replay(func(record wal.Record) error { return nil }))
+
+	// nothing was cut off
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), info.Size())
+}
+
+func TestLog_OversizedHeaderIsATornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	assert.NoError(t, log.Append(wal.Record{TxID: 1, Entries: []wal.Entry{{Key: "key1", OperationType: operation.SET, Value: "value1"}}}))
+	assert.NoError(t, log.Close())
+
+	path := filepath.Join(dir, "wal.log")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	log, err = wal.Open(dir, wal.Options{SyncPolicy: wal.SyncEveryCommit})
+	assert.NoError(t, err)
+	defer log.Close()
+	assert.Len(t, replayAll(t, log), 1)
+}